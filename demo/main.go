@@ -12,7 +12,9 @@ import (
 
 func main() {
 	// renderer := renderers.NewSimpleRenderer(os.Stdout, nil)
-	renderer := renderers.NewInteractiveRenderer(os.Stdout, nil)
+	// renderer := renderers.NewFullScreenRenderer(os.Stdin, os.Stdout, nil)
+	// renderer := renderers.NewInteractiveRenderer(os.Stdout, nil)
+	renderer, _ := renderers.NewAuto(os.Stdout)
 	go renderer.StartDrawing()
 	defer renderer.StopDrawing()
 	log := echelon.NewLogger(echelon.InfoLevel, renderer)
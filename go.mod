@@ -3,7 +3,9 @@ module github.com/cirruslabs/echelon
 go 1.14
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/stretchr/testify v1.6.1
 	golang.org/x/sys v0.0.0-20220928140112-f11e5e49a4ec
 	golang.org/x/text v0.3.8
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -76,3 +76,57 @@ func (entry *LogEntryMessage) GetMessage() string {
 func (entry *LogEntryMessage) GetScopes() []string {
 	return entry.scopes
 }
+
+// LogScopeBadge sets a short label shown next to the title of a scope. Empty text removes the badge.
+type LogScopeBadge struct {
+	scopes []string
+	name   string
+	text   string
+}
+
+func NewLogScopeBadge(name string, text string, scopes ...string) *LogScopeBadge {
+	return &LogScopeBadge{
+		scopes: scopes,
+		name:   name,
+		text:   text,
+	}
+}
+
+func (entry *LogScopeBadge) GetScopes() []string {
+	return entry.scopes
+}
+
+func (entry *LogScopeBadge) Name() string {
+	return entry.name
+}
+
+func (entry *LogScopeBadge) Text() string {
+	return entry.text
+}
+
+// LogScopeRetention overrides the retention policy of a scope for one of the finish types.
+type LogScopeRetention struct {
+	scopes     []string
+	finishType FinishType
+	policy     RetentionPolicy
+}
+
+func NewLogScopeRetention(finishType FinishType, policy RetentionPolicy, scopes ...string) *LogScopeRetention {
+	return &LogScopeRetention{
+		scopes:     scopes,
+		finishType: finishType,
+		policy:     policy,
+	}
+}
+
+func (entry *LogScopeRetention) GetScopes() []string {
+	return entry.scopes
+}
+
+func (entry *LogScopeRetention) FinishType() FinishType {
+	return entry.finishType
+}
+
+func (entry *LogScopeRetention) Policy() RetentionPolicy {
+	return entry.policy
+}
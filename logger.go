@@ -1,11 +1,20 @@
 package echelon
 
-import "io"
+import (
+	"io"
+	"strings"
+)
+
+// maxBatchSize limits how many queued events are delivered to a BatchRenderer at once.
+const maxBatchSize = 1024
 
-type genericLogEntry struct {
-	LogStarted  *LogScopeStarted
-	LogFinished *LogScopeFinished
-	LogEntry    *LogEntryMessage
+// Event is a single log event. Exactly one of the fields is set.
+type Event struct {
+	LogStarted   *LogScopeStarted
+	LogFinished  *LogScopeFinished
+	LogEntry     *LogEntryMessage
+	LogBadge     *LogScopeBadge
+	LogRetention *LogScopeRetention
 }
 
 type LogRendered interface {
@@ -14,10 +23,39 @@ type LogRendered interface {
 	RenderMessage(entry *LogEntryMessage)
 }
 
+// BatchRenderer is an optional interface for renderers that prefer to receive all events
+// queued since the previous delivery at once instead of one call per event.
+type BatchRenderer interface {
+	LogRendered
+	RenderBatch(events []*Event)
+}
+
+// BadgeRenderer is an optional interface for renderers that show badges set via Logger.SetBadge.
+// Other renderers don't receive badges at all.
+type BadgeRenderer interface {
+	LogRendered
+	RenderBadge(entry *LogScopeBadge)
+}
+
+// RetentionRenderer is an optional interface for renderers that keep finished scopes on the screen and let
+// Logger.SetRetention override what's left of them. Other renderers don't receive the overrides at all.
+type RetentionRenderer interface {
+	LogRendered
+	RenderRetention(entry *LogScopeRetention)
+}
+
+// SuspendableRenderer is an optional interface for renderers that own the terminal and have to hand it over
+// while an interactive subprocess (e.g. a password prompt or an editor) is reading from it.
+type SuspendableRenderer interface {
+	LogRendered
+	Suspend()
+	Resume()
+}
+
 type Logger struct {
 	maxLogLevel    LogLevel
 	scopes         []string
-	entriesChannel chan *genericLogEntry
+	entriesChannel chan *Event
 	renderer       LogRendered
 }
 
@@ -30,7 +68,7 @@ func (w *loggerAsWriter) Write(p []byte) (n int, err error) {
 	if w.logger.IsLogLevelEnabled(w.level) {
 		logEntryMessage := NewLogEntryMessage(w.logger.scopes, w.level, string(p))
 		logEntryMessage.raw = true
-		w.logger.entriesChannel <- &genericLogEntry{LogEntry: logEntryMessage}
+		w.logger.entriesChannel <- &Event{LogEntry: logEntryMessage}
 	}
 	return len(p), err
 }
@@ -44,9 +82,14 @@ const (
 )
 
 func NewLogger(level LogLevel, renderer LogRendered) *Logger {
+	entriesChannel := make(chan *Event)
+	if _, ok := renderer.(BatchRenderer); ok {
+		// let producers run ahead of the renderer so there is something to batch
+		entriesChannel = make(chan *Event, maxBatchSize)
+	}
 	logger := &Logger{
 		maxLogLevel:    level,
-		entriesChannel: make(chan *genericLogEntry),
+		entriesChannel: entriesChannel,
 		renderer:       renderer,
 	}
 	go logger.streamEntries(renderer)
@@ -58,18 +101,36 @@ func (logger *Logger) Renderer() LogRendered {
 }
 
 func (logger *Logger) Scoped(scope string) *Logger {
+	// copy scopes so siblings created concurrently don't share the underlying array
+	scopes := make([]string, 0, len(logger.scopes)+1)
+	scopes = append(scopes, logger.scopes...)
 	result := &Logger{
 		maxLogLevel:    logger.maxLogLevel,
-		scopes:         append(logger.scopes, scope),
+		scopes:         append(scopes, scope),
 		entriesChannel: logger.entriesChannel,
+		renderer:       logger.renderer,
 	}
-	result.entriesChannel <- &genericLogEntry{
+	result.entriesChannel <- &Event{
 		LogStarted: NewLogScopeStarted(result.scopes...),
 	}
 	return result
 }
 
+// Interactive runs f with the renderer suspended so f can freely use the terminal. Events logged in the meantime
+// are drawn once f returns.
+func (logger *Logger) Interactive(f func() error) error {
+	if suspendable, ok := logger.renderer.(SuspendableRenderer); ok {
+		suspendable.Suspend()
+		defer suspendable.Resume()
+	}
+	return f()
+}
+
 func (logger *Logger) streamEntries(renderer LogRendered) {
+	if batchRenderer, ok := renderer.(BatchRenderer); ok {
+		logger.streamBatches(batchRenderer)
+		return
+	}
 	for {
 		entry := <-logger.entriesChannel
 		if entry.LogStarted != nil {
@@ -81,9 +142,74 @@ func (logger *Logger) streamEntries(renderer LogRendered) {
 		if entry.LogEntry != nil {
 			renderer.RenderMessage(entry.LogEntry)
 		}
+		if badgeRenderer, ok := renderer.(BadgeRenderer); ok && entry.LogBadge != nil {
+			badgeRenderer.RenderBadge(entry.LogBadge)
+		}
+		if retentionRenderer, ok := renderer.(RetentionRenderer); ok && entry.LogRetention != nil {
+			retentionRenderer.RenderRetention(entry.LogRetention)
+		}
 	}
 }
 
+func (logger *Logger) streamBatches(renderer BatchRenderer) {
+	for {
+		events := []*Event{<-logger.entriesChannel}
+	drain:
+		for len(events) < maxBatchSize {
+			select {
+			case entry := <-logger.entriesChannel:
+				events = append(events, entry)
+			default:
+				break drain
+			}
+		}
+		renderer.RenderBatch(coalesceEvents(events))
+	}
+}
+
+// coalesceEvents merges consecutive raw writes of the same level to the same scope into a single message.
+func coalesceEvents(events []*Event) []*Event {
+	result := make([]*Event, 0, len(events))
+	for i := 0; i < len(events); {
+		j := i + 1
+		for j < len(events) && canBeMerged(events[i].LogEntry, events[j].LogEntry) {
+			j++
+		}
+		if j-i == 1 {
+			result = append(result, events[i])
+			i = j
+			continue
+		}
+		var message strings.Builder
+		for _, event := range events[i:j] {
+			message.WriteString(event.LogEntry.message)
+		}
+		result = append(result, &Event{LogEntry: &LogEntryMessage{
+			Level:   events[i].LogEntry.Level,
+			scopes:  events[i].LogEntry.scopes,
+			message: message.String(),
+			raw:     true,
+		}})
+		i = j
+	}
+	return result
+}
+
+func canBeMerged(one *LogEntryMessage, two *LogEntryMessage) bool {
+	if one == nil || two == nil || !one.raw || !two.raw || one.Level != two.Level {
+		return false
+	}
+	if len(one.scopes) != len(two.scopes) {
+		return false
+	}
+	for i := range one.scopes {
+		if one.scopes[i] != two.scopes[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (logger *Logger) Tracef(format string, args ...interface{}) {
 	logger.Logf(TraceLevel, format, args...)
 }
@@ -106,12 +232,28 @@ func (logger *Logger) Errorf(format string, args ...interface{}) {
 
 func (logger *Logger) Logf(level LogLevel, format string, args ...interface{}) {
 	if logger.IsLogLevelEnabled(level) {
-		logger.entriesChannel <- &genericLogEntry{
+		logger.entriesChannel <- &Event{
 			LogEntry: NewLogEntryMessage(logger.scopes, level, format, args...),
 		}
 	}
 }
 
+// SetBadge shows a short label like a version or a host next to the title of the scope in renderers
+// supporting badges. Badges are shown in the order they were first set, empty text removes the badge.
+func (logger *Logger) SetBadge(name string, text string) {
+	logger.entriesChannel <- &Event{
+		LogBadge: NewLogScopeBadge(name, text, logger.scopes...),
+	}
+}
+
+// SetRetention overrides what's left of the scope on the screen once it finishes with the finish type in renderers
+// supporting retention policies, e.g. to keep the last lines of output of a deployment even when it succeeds.
+func (logger *Logger) SetRetention(finishType FinishType, policy RetentionPolicy) {
+	logger.entriesChannel <- &Event{
+		LogRetention: NewLogScopeRetention(finishType, policy, logger.scopes...),
+	}
+}
+
 func (logger *Logger) AsWriter(level LogLevel) io.Writer {
 	return &loggerAsWriter{logger: logger, level: level}
 }
@@ -129,7 +271,7 @@ func (logger *Logger) Finish(success bool) {
 }
 
 func (logger *Logger) FinishWithType(finishType FinishType) {
-	logger.entriesChannel <- &genericLogEntry{
+	logger.entriesChannel <- &Event{
 		LogFinished: NewLogScopeFinished(finishType, logger.scopes...),
 	}
 }
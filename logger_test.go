@@ -0,0 +1,99 @@
+//nolint:testpackage
+package echelon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rawEntry(level LogLevel, message string, scopes ...string) *Event {
+	entry := NewLogEntryMessage(scopes, level, "%s", message)
+	entry.raw = true
+	return &Event{LogEntry: entry}
+}
+
+func Test_coalesceEvents(t *testing.T) {
+	t.Parallel()
+	events := coalesceEvents([]*Event{
+		{LogStarted: NewLogScopeStarted("foo")},
+		rawEntry(InfoLevel, "Hello, ", "foo"),
+		rawEntry(InfoLevel, "World!\n", "foo"),
+		rawEntry(InfoLevel, "Hi!\n", "bar"),
+		rawEntry(ErrorLevel, "Oops\n", "bar"),
+		{LogEntry: NewLogEntryMessage([]string{"bar"}, ErrorLevel, "Not raw")},
+		rawEntry(ErrorLevel, "Still ", "bar"),
+		rawEntry(ErrorLevel, "raw", "bar"),
+	})
+	assert.Len(t, events, 6)
+	assert.NotNil(t, events[0].LogStarted)
+	assert.Equal(t, "Hello, World!\n", events[1].LogEntry.GetMessage())
+	assert.Equal(t, []string{"foo"}, events[1].LogEntry.GetScopes())
+	assert.Equal(t, "Hi!\n", events[2].LogEntry.GetMessage())
+	assert.Equal(t, "Oops\n", events[3].LogEntry.GetMessage())
+	assert.Equal(t, "Not raw\n", events[4].LogEntry.GetMessage())
+	assert.Equal(t, "Still raw", events[5].LogEntry.GetMessage())
+}
+
+type suspendableRenderer struct {
+	calls []string
+}
+
+func (r *suspendableRenderer) RenderScopeStarted(*LogScopeStarted)   {}
+func (r *suspendableRenderer) RenderScopeFinished(*LogScopeFinished) {}
+func (r *suspendableRenderer) RenderMessage(*LogEntryMessage)        {}
+func (r *suspendableRenderer) Suspend()                              { r.calls = append(r.calls, "suspend") }
+func (r *suspendableRenderer) Resume()                               { r.calls = append(r.calls, "resume") }
+
+func Test_Logger_Interactive(t *testing.T) {
+	t.Parallel()
+	renderer := &suspendableRenderer{}
+	logger := NewLogger(InfoLevel, renderer).Scoped("foo")
+	expectedErr := errors.New("exit status 1")
+	err := logger.Interactive(func() error {
+		renderer.calls = append(renderer.calls, "run")
+		return expectedErr
+	})
+	assert.Equal(t, expectedErr, err)
+	assert.Equal(t, []string{"suspend", "run", "resume"}, renderer.calls)
+}
+
+type badgeRenderer struct {
+	suspendableRenderer
+	badges chan *LogScopeBadge
+}
+
+func (r *badgeRenderer) RenderBadge(entry *LogScopeBadge) {
+	r.badges <- entry
+}
+
+func Test_Logger_SetBadge(t *testing.T) {
+	t.Parallel()
+	renderer := &badgeRenderer{badges: make(chan *LogScopeBadge, 1)}
+	NewLogger(InfoLevel, renderer).Scoped("foo").SetBadge("version", "v1.2")
+	badge := <-renderer.badges
+	assert.Equal(t, []string{"foo"}, badge.GetScopes())
+	assert.Equal(t, "version", badge.Name())
+	assert.Equal(t, "v1.2", badge.Text())
+}
+
+type retentionRenderer struct {
+	suspendableRenderer
+	retentions chan *LogScopeRetention
+}
+
+func (r *retentionRenderer) RenderRetention(entry *LogScopeRetention) {
+	r.retentions <- entry
+}
+
+func Test_Logger_SetRetention(t *testing.T) {
+	t.Parallel()
+	renderer := &retentionRenderer{retentions: make(chan *LogScopeRetention, 1)}
+	policy := RetentionPolicy{Mode: RetainDescription, Lines: 3}
+	NewLogger(InfoLevel, renderer).Scoped("deploy").SetRetention(FinishTypeSucceeded, policy)
+	retention := <-renderer.retentions
+	assert.Equal(t, []string{"deploy"}, retention.GetScopes())
+	assert.Equal(t, FinishTypeSucceeded, retention.FinishType())
+	assert.Equal(t, policy, retention.Policy())
+}
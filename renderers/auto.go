@@ -0,0 +1,206 @@
+package renderers
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/internal/console"
+	"github.com/cirruslabs/echelon/terminal"
+)
+
+// ciVariables are set by CI services, logs of CI builds are not terminals even if they pretend to be.
+var ciVariables = []string{
+	"CI", "CONTINUOUS_INTEGRATION", "BUILD_NUMBER", "GITHUB_ACTIONS", "GITLAB_CI", "CIRRUS_CI", "BUILDKITE",
+	"TF_BUILD", "JENKINS_URL", "TEAMCITY_VERSION", "TRAVIS", "CIRCLECI",
+}
+
+// ConfigFileVariable names the environment variable with the path of the settings file NewAuto loads.
+const ConfigFileVariable = EnvironmentPrefix + "CONFIG"
+
+// AutoConfig overrides the detection of NewAutoWithConfig. The zero value detects everything.
+type AutoConfig struct {
+	// Settings create the renderer. By default they're loaded with LoadSettings from the file in ConfigFileVariable
+	// and the ECHELON_* variables of Environ. Their Renderer is used instead of the detected one unless it's
+	// RendererAuto.
+	Settings *Settings
+	// Renderer forces the renderer taking precedence over the settings.
+	Renderer RendererKind
+	// ColorLevel forces the color level.
+	ColorLevel terminal.ColorLevel
+	// Getenv reads environment variables, os.Getenv by default.
+	Getenv func(key string) string
+	// Environ lists environment variables in the "NAME=value" form to load the settings from, os.Environ() by default.
+	Environ []string
+	// IsTerminal checks if the output is a terminal, by default it asks the operating system.
+	IsTerminal func(out *os.File) bool
+	// Input is where the full-screen renderer reads keys from, os.Stdin by default.
+	Input *os.File
+}
+
+// AutoDecision explains what NewAuto picked and why.
+type AutoDecision struct {
+	Renderer       RendererKind
+	RendererReason string
+	ColorLevel     terminal.ColorLevel
+	ColorReason    string
+	// SettingsErr is why the settings failed to load and the default ones were used instead.
+	SettingsErr error
+}
+
+func (decision AutoDecision) String() string {
+	result := fmt.Sprintf("%s renderer because %s, %s colors because %s",
+		decision.Renderer, decision.RendererReason, decision.ColorLevel, decision.ColorReason)
+	if decision.SettingsErr != nil {
+		result += fmt.Sprintf(", default settings because %v", decision.SettingsErr)
+	}
+	return result
+}
+
+// AutoRendered is the renderer picked by NewAuto, an *InteractiveRenderer, a *FullScreenRenderer or
+// a *SimpleRenderer to pass to echelon.NewLogger.
+type AutoRendered interface {
+	echelon.LogRendered
+	StartDrawing()
+	StopDrawing()
+}
+
+// NewAuto picks the interactive renderer for terminals and the simple renderer for files, pipes, dumb terminals
+// and CI builds and the color level from NO_COLOR, FORCE_COLOR, CLICOLOR, CLICOLOR_FORCE, COLORTERM and TERM.
+// The settings are loaded from the file in ConfigFileVariable and ECHELON_* variables.
+func NewAuto(out *os.File) (AutoRendered, AutoDecision) {
+	return NewAutoWithConfig(out, nil)
+}
+
+func NewAutoWithConfig(out *os.File, autoConfig *AutoConfig) (AutoRendered, AutoDecision) {
+	if autoConfig == nil {
+		autoConfig = &AutoConfig{}
+	}
+	getenv := autoConfig.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	isTerminal := autoConfig.IsTerminal
+	if isTerminal == nil {
+		isTerminal = console.IsTerminal
+	}
+	var decision AutoDecision
+	settings := autoConfig.Settings
+	if settings == nil {
+		environ := autoConfig.Environ
+		if environ == nil {
+			environ = os.Environ()
+		}
+		settings, decision.SettingsErr = LoadSettings(getenv(ConfigFileVariable), environ)
+		if decision.SettingsErr != nil {
+			settings = NewDefaultSettings()
+		}
+	}
+	input := autoConfig.Input
+	if input == nil {
+		input = os.Stdin
+	}
+	outIsTerminal := isTerminal(out)
+
+	decision.Renderer, decision.RendererReason = detectRenderer(autoConfig, settings, getenv, outIsTerminal)
+	decision.ColorLevel, decision.ColorReason = detectColorLevel(autoConfig.ColorLevel, getenv, outIsTerminal)
+
+	// the settings are left intact, they might be shared
+	interactiveConfig := *settings.Interactive
+	simpleConfig := *settings.Simple
+	if decision.ColorLevel == terminal.ColorLevelNone {
+		interactiveConfig.Colors = terminal.NoColorSchema()
+		simpleConfig.Colors = terminal.NoColorSchema()
+	}
+	if settings.Interactive.Theme != nil {
+		interactiveConfig.Theme = themeWithLevel(settings.Interactive.Theme, decision.ColorLevel)
+	}
+	if settings.Simple.Theme != nil {
+		simpleConfig.Theme = themeWithLevel(settings.Simple.Theme, decision.ColorLevel)
+	}
+
+	switch decision.Renderer {
+	case RendererFullScreen:
+		return NewFullScreenRenderer(input, out, &interactiveConfig), decision
+	case RendererSimple:
+		return NewSimpleRendererWithConfig(out, &simpleConfig), decision
+	default:
+		return NewInteractiveRenderer(out, &interactiveConfig), decision
+	}
+}
+
+func detectRenderer(
+	autoConfig *AutoConfig,
+	settings *Settings,
+	getenv func(string) string,
+	outIsTerminal bool,
+) (RendererKind, string) {
+	switch {
+	case autoConfig.Renderer != RendererAuto:
+		return autoConfig.Renderer, "it's forced by the config"
+	case settings.Renderer != RendererAuto:
+		return settings.Renderer, "it's chosen in the settings"
+	case !outIsTerminal:
+		return RendererSimple, "the output is not a terminal"
+	case getenv("TERM") == "dumb":
+		return RendererSimple, "TERM is dumb"
+	}
+	for _, variable := range ciVariables {
+		if value := getenv(variable); value != "" && value != "0" && value != "false" {
+			return RendererSimple, fmt.Sprintf("%s is set, so it's a CI build", variable)
+		}
+	}
+	return RendererInteractive, "the output is a terminal"
+}
+
+func detectColorLevel(
+	forced terminal.ColorLevel,
+	getenv func(string) string,
+	outIsTerminal bool,
+) (terminal.ColorLevel, string) {
+	if forced != terminal.ColorLevelAuto {
+		return forced, "it's forced by the config"
+	}
+	if getenv("NO_COLOR") != "" {
+		return terminal.ColorLevelNone, "NO_COLOR is set"
+	}
+	if force := getenv("FORCE_COLOR"); force != "" {
+		level := terminal.DetectColorLevel(func(key string) string {
+			if key == "FORCE_COLOR" {
+				return force
+			}
+			return ""
+		})
+		return level, fmt.Sprintf("FORCE_COLOR is %s", force)
+	}
+	if force := getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return atLeastBasic(terminal.DetectColorLevel(getenv)), "CLICOLOR_FORCE is set"
+	}
+	if getenv("CLICOLOR") == "0" {
+		return terminal.ColorLevelNone, "CLICOLOR is 0"
+	}
+	if !outIsTerminal {
+		return terminal.ColorLevelNone, "the output is not a terminal"
+	}
+	if getenv("TERM") == "dumb" {
+		return terminal.ColorLevelNone, "TERM is dumb"
+	}
+	return terminal.DetectColorLevel(getenv), "it's detected from COLORTERM and TERM"
+}
+
+func atLeastBasic(level terminal.ColorLevel) terminal.ColorLevel {
+	if level < terminal.ColorLevelBasic {
+		return terminal.ColorLevelBasic
+	}
+	return level
+}
+
+// themeWithLevel returns a copy of the theme downsampled to the level unless the theme is already limited to
+// fewer colors.
+func themeWithLevel(theme *terminal.Theme, level terminal.ColorLevel) *terminal.Theme {
+	result := *theme
+	if result.Level == terminal.ColorLevelAuto || level < result.Level {
+		result.Level = level
+	}
+	return &result
+}
@@ -0,0 +1,145 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAutoTestConfig(outIsTerminal bool, env map[string]string) *AutoConfig {
+	environ := []string{}
+	for key, value := range env {
+		environ = append(environ, key+"="+value)
+	}
+	return &AutoConfig{
+		Getenv: func(key string) string {
+			return env[key]
+		},
+		Environ: environ,
+		IsTerminal: func(*os.File) bool {
+			return outIsTerminal
+		},
+	}
+}
+
+func Test_NewAuto_Decisions(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+
+	for _, testCase := range []struct {
+		outIsTerminal bool
+		env           map[string]string
+		expected      string
+	}{
+		{true, map[string]string{"TERM": "xterm-256color"},
+			"interactive renderer because the output is a terminal, " +
+				"256 colors because it's detected from COLORTERM and TERM"},
+		{false, map[string]string{"TERM": "xterm-256color"},
+			"simple renderer because the output is not a terminal, none colors because the output is not a terminal"},
+		{true, map[string]string{"TERM": "dumb"},
+			"simple renderer because TERM is dumb, none colors because TERM is dumb"},
+		{true, map[string]string{"TERM": "xterm", "CI": "true", "COLORTERM": "truecolor"},
+			"simple renderer because CI is set, so it's a CI build, " +
+				"truecolor colors because it's detected from COLORTERM and TERM"},
+		{true, map[string]string{"TERM": "xterm", "CI": "false"},
+			"interactive renderer because the output is a terminal, " +
+				"basic colors because it's detected from COLORTERM and TERM"},
+		{true, map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "3"},
+			"interactive renderer because the output is a terminal, none colors because NO_COLOR is set"},
+		{false, map[string]string{"FORCE_COLOR": "2"},
+			"simple renderer because the output is not a terminal, 256 colors because FORCE_COLOR is 2"},
+		{false, map[string]string{"CLICOLOR_FORCE": "1", "TERM": "dumb"},
+			"simple renderer because the output is not a terminal, basic colors because CLICOLOR_FORCE is set"},
+		{true, map[string]string{"CLICOLOR": "0", "COLORTERM": "truecolor"},
+			"interactive renderer because the output is a terminal, none colors because CLICOLOR is 0"},
+	} {
+		renderer, decision := NewAutoWithConfig(out, newAutoTestConfig(testCase.outIsTerminal, testCase.env))
+		assert.Equal(t, testCase.expected, decision.String(), testCase.env)
+		switch decision.Renderer {
+		case RendererSimple:
+			assert.IsType(t, &SimpleRenderer{}, renderer)
+		default:
+			assert.IsType(t, &InteractiveRenderer{}, renderer)
+		}
+	}
+}
+
+func Test_NewAuto_Overrides(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+
+	settings := NewDefaultSettings()
+	settings.Renderer = RendererFullScreen
+	autoConfig := newAutoTestConfig(false, nil)
+	autoConfig.Settings = settings
+	renderer, decision := NewAutoWithConfig(out, autoConfig)
+	assert.IsType(t, &FullScreenRenderer{}, renderer)
+	assert.Equal(t, "it's chosen in the settings", decision.RendererReason)
+
+	autoConfig.Renderer = RendererInteractive
+	autoConfig.ColorLevel = terminal.ColorLevel256
+	_, decision = NewAutoWithConfig(out, autoConfig)
+	assert.Equal(t, "interactive renderer because it's forced by the config, 256 colors because it's forced by the config",
+		decision.String())
+}
+
+func Test_NewAuto_Theme(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+
+	settings := NewDefaultSettings()
+	require.NoError(t, settings.LoadEnvironment([]string{"ECHELON_THEME=dark"}))
+	autoConfig := newAutoTestConfig(true, map[string]string{"TERM": "xterm-256color"})
+	autoConfig.Settings = settings
+	renderer, _ := NewAutoWithConfig(out, autoConfig)
+	// the theme is downsampled to the detected level without changing the settings
+	assert.Equal(t, terminal.ColorLevel256, renderer.(*InteractiveRenderer).config.Theme.Level)
+	assert.Equal(t, terminal.ColorLevelAuto, settings.Interactive.Theme.Level)
+
+	autoConfig.Getenv = func(key string) string {
+		return map[string]string{"NO_COLOR": "1"}[key]
+	}
+	autoConfig.IsTerminal = func(*os.File) bool { return false }
+	renderer, _ = NewAutoWithConfig(out, autoConfig)
+	simple := renderer.(*SimpleRenderer)
+	assert.Equal(t, terminal.ColorLevelNone, simple.config.Theme.Level)
+	assert.Equal(t, terminal.NoColorSchema(), simple.colors)
+}
+
+func Test_NewAuto_LoadsSettings(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	path := filepath.Join(dir, "echelon.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("renderer: fullscreen\nvisible_lines: 3\n"), 0600))
+
+	// the environment variables override the file
+	renderer, decision := NewAutoWithConfig(out, newAutoTestConfig(false, map[string]string{
+		ConfigFileVariable:      path,
+		"ECHELON_VISIBLE_LINES": "7",
+	}))
+	require.NoError(t, decision.SettingsErr)
+	require.IsType(t, &FullScreenRenderer{}, renderer)
+	assert.Equal(t, 7, renderer.(*FullScreenRenderer).config.VisibleDescriptionLines)
+
+	// broken settings fall back to the default ones
+	renderer, decision = NewAutoWithConfig(out, newAutoTestConfig(false, map[string]string{
+		"ECHELON_VISIBLE_LINES": "many",
+	}))
+	assert.IsType(t, &SimpleRenderer{}, renderer)
+	assert.Contains(t, decision.String(), ", default settings because ECHELON_VISIBLE_LINES: ")
+}
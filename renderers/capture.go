@@ -0,0 +1,86 @@
+package renderers
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/cirruslabs/echelon/renderers/internal/console"
+)
+
+// captureDrainTimeout limits how long to wait for the captured output after restoring standard streams
+// since subprocesses that inherited them might still keep the pipe open.
+const captureDrainTimeout = time.Second
+
+// CaptureStandardStreams redirects stdout and stderr of the process through a pipe so everything written there,
+// including output of subprocesses inheriting them, is printed above the frame instead of corrupting it.
+// Returns a function that restores the original streams.
+func (r *InteractiveRenderer) CaptureStandardStreams() (func() error, error) {
+	pipeReader, pipeWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	fds := []int{int(os.Stdout.Fd()), int(os.Stderr.Fd())}
+	var originals []*os.File
+	previousOutput := r.setOutput(nil)
+	restore := func() error {
+		r.setOutput(previousOutput)
+		var result error
+		for i, original := range originals {
+			if err := console.RestoreOutput(fds[i], original); err != nil && result == nil {
+				result = err
+			}
+		}
+		return result
+	}
+	for i, fd := range fds {
+		original, err := console.RedirectOutput(fd, pipeWriter)
+		if err != nil {
+			_ = restore()
+			_ = pipeReader.Close()
+			_ = pipeWriter.Close()
+			return nil, err
+		}
+		originals = append(originals, original)
+		if file, ok := previousOutput.(*os.File); ok && file.Fd() == uintptr(fd) {
+			// keep drawing to the terminal
+			r.setOutput(originals[i])
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		_, _ = io.Copy(r, pipeReader)
+	}()
+	return func() error {
+		err := restore()
+		_ = pipeWriter.Close()
+		select {
+		case <-drained:
+		case <-time.After(captureDrainTimeout):
+		}
+		_ = pipeReader.Close()
+		r.flushPendingOutput()
+		return err
+	}, nil
+}
+
+// setOutput continues drawing to another writer and returns the previous one. Nil keeps the current writer.
+func (r *InteractiveRenderer) setOutput(output io.Writer) io.Writer {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	previous := r.output
+	if output != nil && output != previous {
+		_ = r.out.Flush()
+		r.output = output
+		r.out.Reset(output)
+		previousFile, previousIsFile := previous.(*os.File)
+		file, isFile := output.(*os.File)
+		if provider, ok := r.sizeProvider.(*fileSizeProvider); ok && previousIsFile && isFile {
+			// the original file descriptor now points to the pipe
+			provider.replaceFile(previousFile, file)
+		}
+	}
+	return previous
+}
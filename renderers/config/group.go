@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GroupKeyFunc returns the key of the group the scope belongs to given the titles of the scope and its parents.
+// Siblings with the same non-empty key are aggregated into a single row titled with the key. Empty key leaves
+// the scope on its own.
+type GroupKeyFunc func(scopes []string) string
+
+// matrixPattern matches titles of matrix scopes like "test (go1.21, linux)".
+var matrixPattern = regexp.MustCompile(`^(.+?)\s*\(.*\)$`)
+
+// GroupByPattern groups scopes with titles matching the pattern by the first submatch or by the whole match
+// if the pattern has no groups.
+func GroupByPattern(pattern *regexp.Regexp) GroupKeyFunc {
+	return func(scopes []string) string {
+		if len(scopes) == 0 {
+			return ""
+		}
+		match := pattern.FindStringSubmatch(scopes[len(scopes)-1])
+		switch len(match) {
+		case 0:
+			return ""
+		case 1:
+			return match[0]
+		default:
+			return match[1]
+		}
+	}
+}
+
+// GroupMatrix groups matrix scopes like "test (go1.21, linux)" and "test (go1.22, linux)" under "test".
+func GroupMatrix() GroupKeyFunc {
+	return GroupByPattern(matrixPattern)
+}
+
+// GroupCounts are the amounts of members of a group by their state.
+type GroupCounts struct {
+	Passed  int
+	Failed  int
+	Skipped int
+	Running int
+	Pending int
+}
+
+func (counts GroupCounts) Total() int {
+	return counts.Passed + counts.Failed + counts.Skipped + counts.Running + counts.Pending
+}
+
+// String formats the counts like "4/6 passed, 1 running, 1 failed".
+func (counts GroupCounts) String() string {
+	parts := []string{fmt.Sprintf("%d/%d passed", counts.Passed, counts.Total())}
+	if counts.Running > 0 {
+		parts = append(parts, fmt.Sprintf("%d running", counts.Running))
+	}
+	if counts.Pending > 0 {
+		parts = append(parts, fmt.Sprintf("%d pending", counts.Pending))
+	}
+	if counts.Skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", counts.Skipped))
+	}
+	if counts.Failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", counts.Failed))
+	}
+	return strings.Join(parts, ", ")
+}
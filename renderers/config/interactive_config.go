@@ -1,16 +1,55 @@
 package config
 
 import (
+	"github.com/cirruslabs/echelon"
 	"github.com/cirruslabs/echelon/terminal"
 	"runtime"
+	"text/template"
 	"time"
 )
 
 const defaultVisibleLines = 5
+const defaultMaxFrameRate = 30
+const defaultMaxDescriptionLines = 10000
+
+// durationTick is the resolution of the most precise duration shown next to a running scope.
+const durationTick = 100 * time.Millisecond
+
+// FeatureMode controls terminal features which might not be supported everywhere.
+type FeatureMode int
+
+const (
+	// FeatureAuto enables the feature if the terminal seems to support it.
+	FeatureAuto FeatureMode = iota
+	FeatureEnabled
+	FeatureDisabled
+)
+
+// SignalHandling controls what renderers do when the process receives SIGINT, SIGTERM or SIGHUP while drawing.
+type SignalHandling int
+
+const (
+	// SignalsRestoreAndExit restores the terminal, stops drawing and raises the signal again, so the process dies
+	// as if the signal wasn't handled.
+	SignalsRestoreAndExit SignalHandling = iota
+	// SignalsRestore restores the terminal and stops drawing without raising the signal again. Use it if
+	// the application handles the signals itself, otherwise they'd be delivered to it twice.
+	SignalsRestore
+	// SignalsIgnore leaves the signals to the application which should call Restore of the renderer
+	// in its handler.
+	SignalsIgnore
+)
 
 type InteractiveRendererConfig struct {
-	Colors                         *terminal.ColorSchema
-	RefreshRate                    time.Duration
+	// Colors of titles of scopes, nil means terminal.DefaultColorSchema.
+	Colors *terminal.ColorSchema
+	// Theme styles every element of the output separately, e.g. terminal.DarkTheme. It replaces Colors if set.
+	Theme *terminal.Theme
+	// Deprecated: frames are drawn only when something changes, use MaxFrameRate to limit them. Until it's removed,
+	// a positive value is the shortest time between two frames and takes precedence over MaxFrameRate.
+	RefreshRate time.Duration
+	// MaxFrameRate caps how many frames per second are drawn. Slow outputs get even fewer frames.
+	MaxFrameRate                   int
 	ProgressIndicatorFrames        []string
 	ProgressIndicatorCycleDuration time.Duration
 	SuccessStatus                  string
@@ -19,6 +58,67 @@ type InteractiveRendererConfig struct {
 	DescriptionLinesWhenFailed     int
 	DescriptionLinesWhenSkipped    int
 	VisibleDescriptionLines        int
+	// MaxDescriptionLines limits how many of the latest output lines are kept in memory per scope.
+	// Non-positive value means unlimited.
+	MaxDescriptionLines int
+	// SpillDescriptionToDisk enables writing lines that don't fit in memory to a temporary file per scope
+	// so the full output is still available on demand. StopDrawing removes the files unless KeepSpilledOutput is set.
+	SpillDescriptionToDisk bool
+	// KeepSpilledOutput keeps the files after StopDrawing of the interactive and the full-screen renderers, e.g. to
+	// report the full output of failed scopes via WriteScopeOutput afterwards. ReleaseOutput removes them once
+	// they're no longer needed.
+	KeepSpilledOutput bool
+	// CommitFinishedScopes prints top-level scopes once they're finished to the scrollback and redraws
+	// only the scopes that are still running.
+	CommitFinishedScopes bool
+	// SynchronizedOutput wraps every frame in the synchronized update mode (DEC private mode 2026)
+	// so the terminal never shows partially drawn frames.
+	SynchronizedOutput FeatureMode
+	// HideCursor hides the cursor while drawing, the default configs enable it. The cursor is shown again by
+	// StopDrawing, Restore or when the process receives a termination signal, see TerminationSignals.
+	HideCursor bool
+	// TerminationSignals controls what happens when the process receives SIGINT, SIGTERM or SIGHUP while drawing,
+	// SignalsRestoreAndExit by default.
+	TerminationSignals SignalHandling
+	// SpillDirectory is where the temporary files are created. Empty value means the default temporary directory.
+	SpillDirectory string
+	// Layout of nested scopes, LayoutIndented by default.
+	Layout Layout
+	// IndentWidth is the amount of columns each level of nesting is indented by. Zero means aligning children
+	// with the title text of their parent in LayoutIndented and three columns in tree layouts.
+	IndentWidth int
+	// ColumnLayout puts durations, progress of children, counts of warnings and errors and badges in columns
+	// right-aligned to the terminal width and lined up across sibling scopes. Titles are truncated to make space
+	// for the columns. Without a known terminal width titles are drawn as usual.
+	ColumnLayout bool
+	// FitToScreen makes the frame fit the terminal height when possible instead of cutting the lines at the top.
+	// Under pressure the output below titles shrinks to a single line, then succeeded and skipped siblings collapse
+	// into summary lines and then only some of the running siblings are shown. Failed scopes are always shown.
+	// It's disabled by default.
+	FitToScreen bool
+	// GridThreshold is the amount of siblings above which they're shown as a grid of status cells wrapped to the
+	// terminal width with a legend and counts instead of a line each. Failed siblings are expanded below the grid.
+	// Non-positive value, the default, disables the grid, so it's opt-in, e.g. 50.
+	GridThreshold int
+	// GroupKey aggregates siblings with the same key into a single row like "test [4/6 passed, 1 running, 1 failed]",
+	// e.g. GroupMatrix. Nil disables grouping.
+	GroupKey GroupKeyFunc
+	// ExpandFailedGroups shows members of aggregated rows below them once any of the members fails.
+	ExpandFailedGroups bool
+	// SortOrder of siblings, SortByInsertion by default.
+	SortOrder SortOrder
+	// PinFailedToBottom draws scopes that failed or have failed descendants after their siblings on every level,
+	// so failed scopes end up at the bottom of the frame close to the cursor. It takes precedence over SortOrder.
+	PinFailedToBottom bool
+	// RetentionWhenSucceeded, RetentionWhenFailed and RetentionWhenSkipped control what's left of scopes once they
+	// finish with the finish type. Logger.SetRetention overrides them per scope. See Retention for the defaults.
+	RetentionWhenSucceeded echelon.RetentionPolicy
+	RetentionWhenFailed    echelon.RetentionPolicy
+	RetentionWhenSkipped   echelon.RetentionPolicy
+	// TitleTemplate replaces the default "{{.Status}} {{.ColoredName}} {{.FormattedDuration}}" title lines. It's executed
+	// with TemplateData and can be parsed with renderers.ParseTemplate. ColumnLayout keeps its own titles. If it fails,
+	// the default title is drawn instead and each distinct error is printed once above the frame.
+	TitleTemplate *template.Template
 }
 
 func NewDefaultRenderingConfig() *InteractiveRendererConfig {
@@ -31,8 +131,9 @@ func NewDefaultRenderingConfig() *InteractiveRendererConfig {
 func NewDefaultEmojiRenderingConfig() *InteractiveRendererConfig {
 	//nolint:gomnd
 	return &InteractiveRendererConfig{
-		Colors:      terminal.DefaultColorSchema(),
-		RefreshRate: 200 * time.Microsecond,
+		Colors:       terminal.DefaultColorSchema(),
+		MaxFrameRate: defaultMaxFrameRate,
+		HideCursor:   true,
 		ProgressIndicatorFrames: []string{
 			"🕐", "🕑", "🕒", "🕓", "🕔", "🕕", "🕖", "🕗", "🕘", "🕙", "🕚", "🕛",
 		},
@@ -42,6 +143,7 @@ func NewDefaultEmojiRenderingConfig() *InteractiveRendererConfig {
 		SkippedStatus:                  "⏩",
 		DescriptionLinesWhenFailed:     100,
 		DescriptionLinesWhenSkipped:    0,
+		MaxDescriptionLines:            defaultMaxDescriptionLines,
 		VisibleDescriptionLines:        defaultVisibleLines,
 	}
 }
@@ -49,8 +151,9 @@ func NewDefaultEmojiRenderingConfig() *InteractiveRendererConfig {
 func NewDefaultSymbolsOnlyRenderingConfig() *InteractiveRendererConfig {
 	//nolint:gomnd
 	return &InteractiveRendererConfig{
-		Colors:      terminal.DefaultColorSchema(),
-		RefreshRate: 250 * time.Microsecond,
+		Colors:       terminal.DefaultColorSchema(),
+		MaxFrameRate: defaultMaxFrameRate,
+		HideCursor:   true,
 		ProgressIndicatorFrames: []string{
 			"\\", "|", "/", "-",
 		},
@@ -60,6 +163,7 @@ func NewDefaultSymbolsOnlyRenderingConfig() *InteractiveRendererConfig {
 		SkippedStatus:                  "!",
 		DescriptionLinesWhenFailed:     100,
 		DescriptionLinesWhenSkipped:    0,
+		MaxDescriptionLines:            defaultMaxDescriptionLines,
 	}
 }
 
@@ -73,3 +177,28 @@ func (config *InteractiveRendererConfig) CurrentProgressIndicatorFrame() string
 	}
 	return config.ProgressIndicatorFrames[0]
 }
+
+// MinFrameInterval returns the shortest allowed time between two frames.
+func (config *InteractiveRendererConfig) MinFrameInterval() time.Duration {
+	if config.RefreshRate > 0 {
+		return config.RefreshRate
+	}
+	frameRate := config.MaxFrameRate
+	if frameRate <= 0 {
+		frameRate = defaultMaxFrameRate
+	}
+	return time.Second / time.Duration(frameRate)
+}
+
+// AnimationTickInterval returns how often a frame with running scopes changes on its own,
+// either because the progress indicator advanced or because a duration ticked.
+func (config *InteractiveRendererConfig) AnimationTickInterval() time.Duration {
+	if len(config.ProgressIndicatorFrames) == 0 {
+		return durationTick
+	}
+	frameDuration := config.ProgressIndicatorCycleDuration / time.Duration(len(config.ProgressIndicatorFrames))
+	if frameDuration <= 0 || frameDuration > durationTick {
+		return durationTick
+	}
+	return frameDuration
+}
@@ -0,0 +1,66 @@
+package config
+
+import "strings"
+
+// defaultTreeIndentWidth is enough for a guide, a horizontal line and a space.
+const defaultTreeIndentWidth = 3
+
+// Layout controls how nested scopes are laid out.
+type Layout int
+
+const (
+	// LayoutIndented aligns children and output with the title text of their parent.
+	LayoutIndented Layout = iota
+	// LayoutTree connects children with their parent using box-drawing guides like ├─, └─ and │.
+	LayoutTree
+	// LayoutASCIITree is LayoutTree for terminals without box-drawing characters.
+	LayoutASCIITree
+)
+
+// TreeGuides are prefixes of the lines of nested scopes in tree layouts. All of them have the same width.
+type TreeGuides struct {
+	// Branch starts the first line of a child followed by siblings.
+	Branch string
+	// Last starts the first line of the last child.
+	Last string
+	// Vertical starts the rest of the lines of a child followed by siblings.
+	Vertical string
+	// Blank starts the rest of the lines of the last child and the lines of output.
+	Blank string
+}
+
+func (config *InteractiveRendererConfig) paintGuide(guide string) string {
+	if theme := config.Theme; theme != nil {
+		return theme.Render(theme.Guides, guide)
+	}
+	return guide
+}
+
+// Guides returns the guides of the tree layout or nil if the layout is not a tree.
+func (config *InteractiveRendererConfig) Guides() *TreeGuides {
+	var branch, last, horizontal, vertical string
+	switch config.Layout {
+	case LayoutTree:
+		branch, last, horizontal, vertical = "├", "└", "─", "│"
+	case LayoutASCIITree:
+		branch, last, horizontal, vertical = "|", "`", "-", "|"
+	default:
+		return nil
+	}
+	width := config.IndentWidth
+	if width <= 0 {
+		width = defaultTreeIndentWidth
+	}
+	if width < 2 {
+		// keep a space between the guide and the line
+		width = 2
+	}
+	line := strings.Repeat(horizontal, width-2)
+	vertical, branch, last = config.paintGuide(vertical), config.paintGuide(branch+line), config.paintGuide(last+line)
+	return &TreeGuides{
+		Branch:   branch + " ",
+		Last:     last + " ",
+		Vertical: vertical + strings.Repeat(" ", width-1),
+		Blank:    strings.Repeat(" ", width),
+	}
+}
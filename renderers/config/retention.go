@@ -0,0 +1,28 @@
+package config
+
+import "github.com/cirruslabs/echelon"
+
+// Retention returns what's left of scopes finishing with the finish type resolving RetentionDefault: failed scopes
+// keep their children and DescriptionLinesWhenFailed lines of output, skipped scopes keep their children and
+// DescriptionLinesWhenSkipped lines of output if it's set and the rest collapse to their titles.
+func (config *InteractiveRendererConfig) Retention(finishType echelon.FinishType) echelon.RetentionPolicy {
+	switch finishType {
+	case echelon.FinishTypeFailed:
+		if config.RetentionWhenFailed.Mode != echelon.RetentionDefault {
+			return config.RetentionWhenFailed
+		}
+		return echelon.RetentionPolicy{Mode: echelon.RetainChildren, Lines: config.DescriptionLinesWhenFailed}
+	case echelon.FinishTypeSkipped:
+		if config.RetentionWhenSkipped.Mode != echelon.RetentionDefault {
+			return config.RetentionWhenSkipped
+		}
+		if config.DescriptionLinesWhenSkipped != 0 {
+			return echelon.RetentionPolicy{Mode: echelon.RetainChildren, Lines: config.DescriptionLinesWhenSkipped}
+		}
+	default:
+		if config.RetentionWhenSucceeded.Mode != echelon.RetentionDefault {
+			return config.RetentionWhenSucceeded
+		}
+	}
+	return echelon.RetentionPolicy{Mode: echelon.CollapseToTitle}
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"text/template"
+
+	"github.com/cirruslabs/echelon/terminal"
+)
+
+type SimpleRendererConfig struct {
+	Colors *terminal.ColorSchema
+	// Theme styles the lines by the state of the scopes instead of the Colors when set.
+	Theme *terminal.Theme
+	// GroupKey prints a summary line like "test [4/6 passed, 1 failed]" every time all of the started siblings
+	// with the same key have finished. Nil disables the summaries.
+	GroupKey GroupKeyFunc
+	// StartedTemplate, SucceededTemplate, FailedTemplate and SkippedTemplate replace the default lines printed when
+	// scopes start and finish, e.g. "{{quote .Name}} succeeded in {{.FormattedDuration}}!". They're executed with
+	// TemplateData and can be parsed with renderers.ParseTemplate. Nil means the default line. If a template fails,
+	// the default line is printed instead and each distinct error is printed once before it.
+	StartedTemplate   *template.Template
+	SucceededTemplate *template.Template
+	FailedTemplate    *template.Template
+	SkippedTemplate   *template.Template
+}
+
+func NewDefaultSimpleRendererConfig() *SimpleRendererConfig {
+	return &SimpleRendererConfig{
+		Colors: terminal.DefaultColorSchema(),
+	}
+}
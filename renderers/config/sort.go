@@ -0,0 +1,16 @@
+package config
+
+// SortOrder controls the order siblings are drawn in. Siblings only move when their state changes, e.g. once they
+// start or finish, so that frames stay cheap to redraw incrementally.
+type SortOrder int
+
+const (
+	// SortByInsertion draws siblings in the order they were started.
+	SortByInsertion SortOrder = iota
+	// SortRunningFirst draws running siblings before the rest.
+	SortRunningFirst
+	// SortFailedFirst draws failed siblings before the rest.
+	SortFailedFirst
+	// SortLongestRunningFirst draws running siblings starting with the one that was started first before the rest.
+	SortLongestRunningFirst
+)
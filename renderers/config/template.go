@@ -0,0 +1,36 @@
+package config
+
+import (
+	"time"
+
+	"github.com/cirruslabs/echelon/utils"
+)
+
+// TemplateData is what title and message templates are executed with.
+type TemplateData struct {
+	// Name is the title of the scope.
+	Name string
+	// ColoredName is the name in the color of the title. It's only set for titles of the interactive renderer.
+	ColoredName string
+	// Path has titles of the scope and its parents starting with the top-level one.
+	Path []string
+	// Depth is 1 for top-level scopes, 2 for their children and so on.
+	Depth int
+	// Status is the status symbol or the progress indicator in the interactive renderer
+	// and one of "started", "succeeded", "failed" or "skipped" in the simple renderer.
+	Status string
+	// StartTime is the wall-clock time the scope was started at, zero if it hasn't started yet.
+	StartTime time.Time
+	// Duration is how long the scope has been running or took to finish.
+	Duration time.Duration
+	// Completed and Total are the amounts of finished and all children.
+	Completed int
+	Total     int
+	// Fields are badges set via Logger.SetBadge by their names.
+	Fields map[string]string
+}
+
+// FormattedDuration formats the duration the way renderers do by default.
+func (data TemplateData) FormattedDuration() string {
+	return utils.FormatDuration(data.Duration, data.Total == 0)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/terminal"
+)
+
+// ScopeState picks the style of the title of a scope.
+type ScopeState int
+
+const (
+	StatePending ScopeState = iota
+	StateRunning
+	StateSucceeded
+	StateFailed
+	StateSkipped
+)
+
+// TitleStyle returns the style of titles of scopes in the state.
+func (state ScopeState) TitleStyle(theme *terminal.Theme) terminal.Style {
+	switch state {
+	case StateRunning:
+		return theme.RunningTitle
+	case StateSucceeded:
+		return theme.SucceededTitle
+	case StateFailed:
+		return theme.FailedTitle
+	case StateSkipped:
+		return theme.SkippedTitle
+	default:
+		return theme.PendingTitle
+	}
+}
+
+// ColorSchema returns Colors or the default colors if they aren't set, e.g. because Theme replaces them.
+func (config *InteractiveRendererConfig) ColorSchema() *terminal.ColorSchema {
+	if config.Colors == nil {
+		return terminal.DefaultColorSchema()
+	}
+	return config.Colors
+}
+
+// PaintTitle styles the text like titles of scopes in the state with the theme or with the colors if there's
+// no theme.
+func (config *InteractiveRendererConfig) PaintTitle(state ScopeState, text string) string {
+	if theme := config.Theme; theme != nil {
+		return theme.Render(state.TitleStyle(theme), text)
+	}
+	switch state {
+	case StateSucceeded:
+		return terminal.GetColoredText(config.ColorSchema().SuccessColor, text)
+	case StateFailed:
+		return terminal.GetColoredText(config.ColorSchema().FailureColor, text)
+	default:
+		return terminal.GetColoredText(config.ColorSchema().NeutralColor, text)
+	}
+}
+
+// PaintDuration styles durations of scopes, they're not styled without a theme.
+func (config *InteractiveRendererConfig) PaintDuration(text string) string {
+	if theme := config.Theme; theme != nil {
+		return theme.Render(theme.Duration, text)
+	}
+	return text
+}
+
+// PaintSpinner styles the progress indicator, it's not styled without a theme.
+func (config *InteractiveRendererConfig) PaintSpinner(text string) string {
+	if theme := config.Theme; theme != nil {
+		return theme.Render(theme.Spinner, text)
+	}
+	return text
+}
+
+// PaintMessage styles every line of a message logged at the level, messages are not styled without a theme.
+func (config *InteractiveRendererConfig) PaintMessage(level echelon.LogLevel, message string) string {
+	theme := config.Theme
+	if theme == nil {
+		return message
+	}
+	var style terminal.Style
+	switch level {
+	case echelon.ErrorLevel:
+		style = theme.ErrorMessage
+	case echelon.WarnLevel:
+		style = theme.WarnMessage
+	case echelon.InfoLevel:
+		style = theme.InfoMessage
+	case echelon.DebugLevel:
+		style = theme.DebugMessage
+	case echelon.TraceLevel:
+		style = theme.TraceMessage
+	}
+	if style.Sequence(theme.Level) == "" {
+		return message
+	}
+	// lines of output are drawn separately, so each of them has to be styled on its own
+	lines := strings.Split(message, "\n")
+	for i, line := range lines {
+		lines[i] = theme.Render(style, line)
+	}
+	return strings.Join(lines, "\n")
+}
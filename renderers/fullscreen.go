@@ -0,0 +1,790 @@
+package renderers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/renderers/internal/console"
+	"github.com/cirruslabs/echelon/renderers/internal/node"
+	"github.com/cirruslabs/echelon/terminal"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+const reverseVideo = "\u001B[7m"
+const resetAttributes = "\u001B[0m"
+
+// default screen size when the terminal doesn't report one
+const defaultScreenWidth = 80
+const defaultScreenHeight = 24
+
+// FullScreenRenderer shows all scopes in the alternate screen buffer and lets users browse them with the keyboard:
+//
+//	↑/↓ or k/j   select the previous or the next scope
+//	PgUp/PgDn    move the selection by a screen
+//	Home/End     select the first or the last scope
+//	Enter/Space  expand or collapse the full output of the selected scope, →/← only expand/collapse
+//	n            select the next failed scope
+//	d            show or hide debug and trace messages
+//
+// Unlike InteractiveRenderer it keeps children and output of finished scopes so they can be inspected.
+// Once drawing is stopped the primary screen buffer is restored and a static summary of the scopes is printed.
+type FullScreenRenderer struct {
+	input        *os.File
+	sizeProvider SizeProvider
+	out          *bufio.Writer
+	rootNode     *node.EchelonNode
+	snapshot     atomic.Value
+	config       *config.InteractiveRendererConfig
+	retentions   retentionOverrides
+	stopped      int32
+	resized      int32
+	dirty        chan struct{}
+
+	drawLock          sync.Mutex // guards everything below
+	drawing           bool       // StartDrawing was called
+	terminalPrepared  bool
+	suspensions       int // nested Suspend calls without a matching Resume
+	restoreInput      func() error
+	keyReader         *console.KeyReader
+	keysDone          chan struct{} // closed once readKeys returns
+	screenWidth       int
+	screenHeight      int
+	currentFrameLines []string
+	selected          []string                // path of the selected scope
+	expanded          map[string]bool         // scopes by joined path with their full output shown
+	outputs           map[string]cachedOutput // full output of the expanded scopes by joined path
+	showDebug         bool
+	offset            int // first row of the tree on the screen
+
+	StubRenderer
+}
+
+// cachedOutput is the full output of a scope split into lines, so spilled output isn't read on every frame.
+type cachedOutput struct {
+	snapshot *node.Snapshot
+	debug    bool // debug and trace messages are included
+	lines    []string
+}
+
+// NewFullScreenRenderer creates a renderer reading key presses from the input terminal and drawing to the output
+// one, usually os.Stdin and os.Stdout.
+func NewFullScreenRenderer(
+	input *os.File,
+	out *os.File,
+	rendererConfig *config.InteractiveRendererConfig,
+) *FullScreenRenderer {
+	return NewFullScreenRendererForWriter(input, out, NewFileSizeProvider(out), rendererConfig)
+}
+
+// NewFullScreenRendererForWriter draws to any writer connected to a terminal, e.g. a channel of an SSH session,
+// with the size of the terminal reported by the provider. Key presses are read from the input terminal, nil input
+// means the scopes can only be watched. Nil provider means the size of the file if out is a file and an unknown
+// size otherwise.
+func NewFullScreenRendererForWriter(
+	input *os.File,
+	out io.Writer,
+	sizeProvider SizeProvider,
+	rendererConfig *config.InteractiveRendererConfig,
+) *FullScreenRenderer {
+	if rendererConfig == nil {
+		rendererConfig = config.NewDefaultRenderingConfig()
+	}
+	if sizeProvider == nil {
+		if file, ok := out.(*os.File); ok {
+			sizeProvider = NewFileSizeProvider(file)
+		} else {
+			sizeProvider = NewFixedSizeProvider(0, 0)
+		}
+	}
+	width, height := sizeProvider.Size()
+	result := &FullScreenRenderer{
+		input:        input,
+		sizeProvider: sizeProvider,
+		out:          bufio.NewWriterSize(out, defaultFrameBufSize),
+		rootNode:     node.NewEchelonNode("root", rendererConfig),
+		config:       rendererConfig,
+		retentions:   make(retentionOverrides),
+		dirty:        make(chan struct{}, 1),
+		screenWidth:  width,
+		screenHeight: height,
+		expanded:     make(map[string]bool),
+		outputs:      make(map[string]cachedOutput),
+	}
+	result.publish()
+	return result
+}
+
+func (r *FullScreenRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	findChildNode(r.rootNode, entry.GetScopes()).Start()
+	r.publish()
+}
+
+func (r *FullScreenRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+	policy := r.retention(entry.GetScopes(), entry.FinishType())
+	r.finishNode(r.rootNode, findChildNode(r.rootNode, entry.GetScopes()), entry.FinishType(), policy)
+	r.publish()
+}
+
+func (r *FullScreenRenderer) RenderRetention(entry *echelon.LogScopeRetention) {
+	r.retentions.set(entry)
+}
+
+// retention returns the policy for the scope set via Logger.SetRetention or in the config. Unlike
+// InteractiveRenderer, scopes without a policy are kept entirely so they can be inspected.
+func (r *FullScreenRenderer) retention(scopes []string, finishType echelon.FinishType) echelon.RetentionPolicy {
+	if policy, ok := r.retentions.take(scopes, finishType); ok {
+		return policy
+	}
+	switch finishType {
+	case echelon.FinishTypeSucceeded:
+		return r.config.RetentionWhenSucceeded
+	case echelon.FinishTypeFailed:
+		if r.config.RetentionWhenFailed.Mode == echelon.RetentionDefault {
+			return echelon.RetentionPolicy{Mode: echelon.RetainChildren, Lines: r.config.DescriptionLinesWhenFailed}
+		}
+		return r.config.RetentionWhenFailed
+	default:
+		return r.config.RetentionWhenSkipped
+	}
+}
+
+func (r *FullScreenRenderer) finishNode(
+	root *node.EchelonNode,
+	n *node.EchelonNode,
+	finishType echelon.FinishType,
+	policy echelon.RetentionPolicy,
+) {
+	switch policy.Mode {
+	case echelon.RetainChildren:
+		n.SetVisibleDescriptionLines(policy.Lines)
+	case echelon.RetainDescription:
+		if n != root {
+			n.ClearAllChildren()
+		}
+		n.SetVisibleDescriptionLines(policy.Lines)
+	case echelon.CollapseToTitle, echelon.RemoveScope:
+		if n != root {
+			n.ClearAllChildren()
+			n.ClearDescription()
+		}
+	case echelon.RetentionDefault:
+	}
+	switch finishType {
+	case echelon.FinishTypeSucceeded:
+		n.CompleteWithColor(r.config.SuccessStatus, r.config.ColorSchema().SuccessColor)
+	case echelon.FinishTypeFailed:
+		n.CompleteWithColor(r.config.FailureStatus, r.config.ColorSchema().FailureColor)
+	case echelon.FinishTypeSkipped:
+		n.CompleteWithColor(r.config.SkippedStatus, r.config.ColorSchema().NeutralColor)
+	}
+	if policy.Mode == echelon.RemoveScope && n != root {
+		n.Remove()
+	}
+}
+
+func (r *FullScreenRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+	message := r.config.PaintMessage(entry.Level, entry.GetMessage())
+	n := findChildNode(r.rootNode, entry.GetScopes())
+	// debug and trace messages are only shown on demand
+	if entry.Level > echelon.InfoLevel {
+		n.AppendVerboseDescription(message)
+	} else {
+		n.AppendDescription(message)
+	}
+	r.publish()
+}
+
+func (r *FullScreenRenderer) publish() {
+	r.snapshot.Store(r.rootNode.Snapshot())
+	r.markDirty()
+}
+
+// markDirty wakes up the drawing loop without blocking if it's already scheduled to draw.
+func (r *FullScreenRenderer) markDirty() {
+	select {
+	case r.dirty <- struct{}{}:
+	default:
+	}
+}
+
+func (r *FullScreenRenderer) latestSnapshot() *node.Snapshot {
+	return r.snapshot.Load().(*node.Snapshot)
+}
+
+// WriteScopeOutput writes the whole output of a scope including debug and trace messages and lines that were
+// spilled to disk. It can be called from any goroutine.
+func (r *FullScreenRenderer) WriteScopeOutput(w io.Writer, scopes ...string) error {
+	snapshot := r.latestSnapshot().FindChild(scopes...)
+	if snapshot == nil {
+		return fmt.Errorf("%w: %s", ErrScopeNotFound, strings.Join(scopes, "/"))
+	}
+	return snapshot.WriteFullDescription(w)
+}
+
+// ReleaseOutput removes temporary files with spilled output of all scopes. StopDrawing calls it unless
+// KeepSpilledOutput is set, then call it once the full output is no longer needed, e.g. after reporting failures.
+func (r *FullScreenRenderer) ReleaseOutput() {
+	r.latestSnapshot().ReleaseOutput()
+}
+
+func (r *FullScreenRenderer) StartDrawing() {
+	_ = console.PrepareTerminalEnvironment()
+	r.prepareTerminal()
+	// don't leave the terminal in the alternate screen if the process is about to die
+	stopHandling := handleTermination(r.config.TerminationSignals, r.Restore)
+	defer stopHandling()
+	resizes := make(chan struct{}, 1)
+	r.sizeProvider.NotifyResize(resizes)
+	defer func() {
+		r.sizeProvider.StopNotifyResize(resizes)
+		close(resizes)
+	}()
+	go func() {
+		for range resizes {
+			atomic.StoreInt32(&r.resized, 1)
+			r.markDirty()
+		}
+	}()
+	r.startReadingKeys()
+	for atomic.LoadInt32(&r.stopped) == 0 {
+		frameStart := time.Now()
+		r.DrawFrame()
+		time.Sleep(r.config.MinFrameInterval() - time.Since(frameStart))
+		r.waitForChanges()
+	}
+}
+
+func (r *FullScreenRenderer) prepareTerminal() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	r.drawing = true
+	if r.suspensions > 0 || atomic.LoadInt32(&r.stopped) != 0 {
+		// Resume or nobody takes the terminal
+		return
+	}
+	// without reading single key presses the screen is still useful to watch the progress
+	if r.input != nil {
+		r.restoreInput, _ = console.EnableKeyboardInput(r.input)
+	}
+	if r.restoreInput != nil {
+		r.keyReader, _ = console.NewKeyReader(r.input)
+	}
+	terminal.EnterAlternateScreen(r.out)
+	_, _ = r.out.WriteString(disableAutoWrap)
+	_, _ = r.out.WriteString(hideCursor)
+	_ = r.out.Flush()
+	r.currentFrameLines = nil
+	r.terminalPrepared = true
+}
+
+func (r *FullScreenRenderer) restoreTerminal() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	if !r.terminalPrepared {
+		return
+	}
+	_, _ = r.out.WriteString(enableAutoWrap)
+	_, _ = r.out.WriteString(showCursor)
+	terminal.LeaveAlternateScreen(r.out)
+	if r.restoreInput != nil {
+		_ = r.restoreInput()
+		r.restoreInput = nil
+	}
+	r.terminalPrepared = false
+}
+
+// waitForChanges blocks until an event changed the tree or a running scope needs its progress redrawn.
+func (r *FullScreenRenderer) waitForChanges() {
+	if !r.latestSnapshot().HasRunningNodes() {
+		<-r.dirty
+		return
+	}
+	tick := time.NewTimer(r.config.AnimationTickInterval())
+	defer tick.Stop()
+	select {
+	case <-r.dirty:
+	case <-tick.C:
+	}
+}
+
+// Suspend leaves the alternate screen and stops reading key presses, so the terminal can be handed over, e.g. to
+// an interactive subprocess. Events received while suspended still update the scopes but nothing is drawn until
+// the matching Resume.
+func (r *FullScreenRenderer) Suspend() {
+	r.drawLock.Lock()
+	r.suspensions++
+	first := r.suspensions == 1
+	r.drawLock.Unlock()
+	if !first {
+		return
+	}
+	r.stopReadingKeys()
+	r.restoreTerminal()
+}
+
+// Resume takes the terminal back after Suspend, enters the alternate screen again and redraws it from scratch.
+func (r *FullScreenRenderer) Resume() {
+	r.drawLock.Lock()
+	if r.suspensions == 0 {
+		r.drawLock.Unlock()
+		return
+	}
+	r.suspensions--
+	last := r.suspensions == 0 && r.drawing
+	r.drawLock.Unlock()
+	if !last {
+		return
+	}
+	r.prepareTerminal()
+	r.startReadingKeys()
+	r.DrawFrame()
+}
+
+// Restore stops drawing and reading key presses and leaves the alternate screen right away without printing
+// the summary, e.g. from a termination signal handler of the application.
+func (r *FullScreenRenderer) Restore() {
+	atomic.StoreInt32(&r.stopped, 1)
+	r.markDirty()
+	r.stopReadingKeys()
+	r.restoreTerminal()
+}
+
+// StopDrawing stops reading key presses, leaves the alternate screen, prints the summary and removes
+// the temporary files with spilled output unless KeepSpilledOutput is set. Input typed afterwards is left
+// for the application.
+func (r *FullScreenRenderer) StopDrawing() {
+	atomic.StoreInt32(&r.stopped, 1)
+	// wake up the drawing loop so it can exit
+	r.markDirty()
+	r.stopReadingKeys()
+	r.restoreTerminal()
+	r.printSummary()
+	if !r.config.KeepSpilledOutput {
+		r.ReleaseOutput()
+	}
+}
+
+func (r *FullScreenRenderer) DrawFrame() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	if !r.terminalPrepared {
+		return
+	}
+	if atomic.SwapInt32(&r.resized, 0) != 0 {
+		r.screenWidth, r.screenHeight = r.sizeProvider.Size()
+		// the terminal might have reflowed the screen
+		r.currentFrameLines = nil
+	}
+	lines := r.renderScreen()
+	terminal.UpdateScreen(r.out, r.currentFrameLines, lines)
+	r.currentFrameLines = lines
+}
+
+func (r *FullScreenRenderer) size() (int, int) {
+	width, height := r.screenWidth, r.screenHeight
+	if width <= 0 {
+		width = defaultScreenWidth
+	}
+	if height <= 0 {
+		height = defaultScreenHeight
+	}
+	return width, height
+}
+
+// screenRow is either a title of a scope or a line of its expanded output.
+type screenRow struct {
+	text     string
+	path     []string // nil for lines of output
+	snapshot *node.Snapshot
+}
+
+func (r *FullScreenRenderer) buildRows() []screenRow {
+	return r.appendRows(nil, r.latestSnapshot(), nil, 0)
+}
+
+func (r *FullScreenRenderer) appendRows(
+	rows []screenRow,
+	snapshot *node.Snapshot,
+	path []string,
+	depth int,
+) []screenRow {
+	indent := strings.Repeat("  ", depth)
+	for _, child := range snapshot.GetChildren() {
+		childPath := append(path[:len(path):len(path)], child.Title())
+		rows = append(rows, screenRow{text: indent + child.RenderTitle(0), path: childPath, snapshot: child})
+		if key := strings.Join(childPath, "\x00"); r.expanded[key] {
+			for _, line := range r.fullOutput(key, child) {
+				rows = append(rows, screenRow{text: indent + "    " + line})
+			}
+		}
+		rows = r.appendRows(rows, child, childPath, depth+1)
+	}
+	return rows
+}
+
+// fullOutput returns the lines of the whole output of the expanded scope reading them again only if it changed
+// or debug messages were shown or hidden.
+func (r *FullScreenRenderer) fullOutput(key string, snapshot *node.Snapshot) []string {
+	cached, ok := r.outputs[key]
+	if ok && cached.debug == r.showDebug && snapshot.SameDescription(cached.snapshot) {
+		return cached.lines
+	}
+	var output bytes.Buffer
+	if r.showDebug {
+		_ = snapshot.WriteFullDescription(&output)
+	} else {
+		_ = snapshot.WriteFullDescriptionWithoutVerbose(&output)
+	}
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	r.outputs[key] = cachedOutput{snapshot: snapshot, debug: r.showDebug, lines: lines}
+	return lines
+}
+
+func (r *FullScreenRenderer) collapse(key string) {
+	delete(r.expanded, key)
+	delete(r.outputs, key)
+}
+
+// selectableRows returns indexes of the rows with titles and the position of the selected one among them.
+func (r *FullScreenRenderer) selectableRows(rows []screenRow) ([]int, int) {
+	var result []int
+	selected := 0
+	for i, row := range rows {
+		if row.path == nil {
+			continue
+		}
+		if equalPaths(row.path, r.selected) {
+			selected = len(result)
+		}
+		result = append(result, i)
+	}
+	return result, selected
+}
+
+func equalPaths(one []string, two []string) bool {
+	if len(one) != len(two) {
+		return false
+	}
+	for i := range one {
+		if one[i] != two[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *FullScreenRenderer) isFailed(snapshot *node.Snapshot) bool {
+	return snapshot.HasCompleted() && snapshot.Status() == r.config.FailureStatus
+}
+
+func (r *FullScreenRenderer) renderScreen() []string {
+	width, height := r.size()
+	rows := r.buildRows()
+	selectable, selected := r.selectableRows(rows)
+	selectedRow := -1
+	if len(selectable) > 0 {
+		selectedRow = selectable[selected]
+	}
+	// keep the last line for the status bar
+	treeHeight := height - 1
+	if selectedRow >= 0 && selectedRow < r.offset {
+		r.offset = selectedRow
+	}
+	if selectedRow >= r.offset+treeHeight {
+		r.offset = selectedRow - treeHeight + 1
+	}
+	if maxOffset := len(rows) - treeHeight; r.offset > maxOffset {
+		r.offset = maxOffset
+	}
+	if r.offset < 0 {
+		r.offset = 0
+	}
+	result := make([]string, 0, height)
+	for i := r.offset; i < len(rows) && len(result) < treeHeight; i++ {
+		marker := "  "
+		if i == selectedRow {
+			marker = "> "
+		}
+		result = append(result, terminal.Truncate(marker+rows[i].text, width, terminal.Ellipsis))
+	}
+	for len(result) < treeHeight {
+		result = append(result, "")
+	}
+	debug := "off"
+	if r.showDebug {
+		debug = "on"
+	}
+	statusBar := fmt.Sprintf(" %d/%d  ↑↓ select  ⏎ output  n next failure  d debug: %s",
+		selected+1, len(selectable), debug)
+	statusBar = terminal.Truncate(statusBar, width, terminal.Ellipsis)
+	padding := strings.Repeat(" ", width-terminal.StringWidth(statusBar))
+	result = append(result, reverseVideo+statusBar+padding+resetAttributes)
+	return result
+}
+
+type key int
+
+const (
+	keyUp key = iota
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyToggle
+	keyExpand
+	keyCollapse
+	keyNextFailure
+	keyToggleDebug
+)
+
+var keySequences = []struct {
+	sequence string
+	key      key
+}{
+	{"\x1B[A", keyUp},
+	{"\x1BOA", keyUp},
+	{"k", keyUp},
+	{"\x1B[B", keyDown},
+	{"\x1BOB", keyDown},
+	{"j", keyDown},
+	{"\x1B[5~", keyPageUp},
+	{"\x1B[6~", keyPageDown},
+	{"\x1B[H", keyHome},
+	{"\x1BOH", keyHome},
+	{"\x1B[1~", keyHome},
+	{"\x1B[F", keyEnd},
+	{"\x1BOF", keyEnd},
+	{"\x1B[4~", keyEnd},
+	{"\r", keyToggle},
+	{"\n", keyToggle},
+	{" ", keyToggle},
+	{"\x1B[C", keyExpand},
+	{"\x1BOC", keyExpand},
+	{"\x1B[D", keyCollapse},
+	{"\x1BOD", keyCollapse},
+	{"n", keyNextFailure},
+	{"d", keyToggleDebug},
+}
+
+// parseKeys returns the keys pressed and the beginning of an escape sequence that hasn't been read completely.
+// Unknown keys are skipped.
+func parseKeys(data []byte) ([]key, []byte) {
+	var result []key
+parse:
+	for len(data) > 0 {
+		for _, s := range keySequences {
+			if bytes.HasPrefix(data, []byte(s.sequence)) {
+				result = append(result, s.key)
+				data = data[len(s.sequence):]
+				continue parse
+			}
+			if len(data) < len(s.sequence) && strings.HasPrefix(s.sequence, string(data)) {
+				break parse
+			}
+		}
+		skip := unknownKeyLength(data)
+		if skip == 0 {
+			break
+		}
+		data = data[skip:]
+	}
+	return result, data
+}
+
+// unknownKeyLength returns the length of the unknown key at the beginning or zero if it's incomplete.
+func unknownKeyLength(data []byte) int {
+	if data[0] != '\x1B' {
+		_, size := utf8.DecodeRune(data)
+		return size
+	}
+	if len(data) == 1 {
+		return 0
+	}
+	if data[1] != '[' && data[1] != 'O' {
+		return 1
+	}
+	for i := 2; i < len(data); i++ {
+		if data[i] >= 0x40 && data[i] <= 0x7E {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (r *FullScreenRenderer) startReadingKeys() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	if r.keyReader == nil {
+		return
+	}
+	r.keysDone = make(chan struct{})
+	go r.readKeys(r.keyReader, r.keysDone)
+}
+
+// stopReadingKeys cancels the pending read and waits for readKeys to return, so it doesn't consume input
+// after the terminal is restored.
+func (r *FullScreenRenderer) stopReadingKeys() {
+	r.drawLock.Lock()
+	reader, done := r.keyReader, r.keysDone
+	r.keyReader, r.keysDone = nil, nil
+	r.drawLock.Unlock()
+	if reader == nil {
+		return
+	}
+	reader.Cancel()
+	if done != nil {
+		<-done
+	}
+	_ = reader.Close()
+}
+
+func (r *FullScreenRenderer) readKeys(reader *console.KeyReader, done chan struct{}) {
+	defer close(done)
+	buf := make([]byte, 256)
+	var pending []byte
+	for {
+		n, err := reader.Read(buf)
+		if atomic.LoadInt32(&r.stopped) != 0 {
+			return
+		}
+		if n > 0 {
+			var keys []key
+			keys, pending = parseKeys(append(pending, buf[:n]...))
+			r.handleKeys(keys)
+			// respond right away even if the drawing loop is waiting for the next frame
+			r.DrawFrame()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (r *FullScreenRenderer) handleKeys(keys []key) {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	for _, k := range keys {
+		r.handleKey(k)
+	}
+}
+
+func (r *FullScreenRenderer) handleKey(k key) {
+	rows := r.buildRows()
+	selectable, selected := r.selectableRows(rows)
+	if len(selectable) == 0 {
+		if k == keyToggleDebug {
+			r.showDebug = !r.showDebug
+		}
+		return
+	}
+	_, height := r.size()
+	selectedKey := strings.Join(rows[selectable[selected]].path, "\x00")
+	switch k {
+	case keyUp:
+		selected--
+	case keyDown:
+		selected++
+	case keyPageUp:
+		selected -= height - 1
+	case keyPageDown:
+		selected += height - 1
+	case keyHome:
+		selected = 0
+	case keyEnd:
+		selected = len(selectable) - 1
+	case keyToggle:
+		if r.expanded[selectedKey] {
+			r.collapse(selectedKey)
+		} else {
+			r.expanded[selectedKey] = true
+		}
+	case keyExpand:
+		r.expanded[selectedKey] = true
+	case keyCollapse:
+		r.collapse(selectedKey)
+	case keyNextFailure:
+		for i := 1; i <= len(selectable); i++ {
+			candidate := (selected + i) % len(selectable)
+			if r.isFailed(rows[selectable[candidate]].snapshot) {
+				selected = candidate
+				break
+			}
+		}
+	case keyToggleDebug:
+		r.showDebug = !r.showDebug
+	}
+	if selected < 0 {
+		selected = 0
+	}
+	if selected >= len(selectable) {
+		selected = len(selectable) - 1
+	}
+	r.selected = rows[selectable[selected]].path
+}
+
+// printSummary prints top-level scopes and the failed scopes below them with their last lines of output.
+func (r *FullScreenRenderer) printSummary() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	width, _ := r.size()
+	var lines []string
+	for _, child := range r.latestSnapshot().GetChildren() {
+		lines = r.appendSummary(lines, child, "", width)
+	}
+	// titles fell back to the default ones
+	for _, err := range r.rootNode.TakeTemplateErrors() {
+		lines = append(lines, err.Error())
+	}
+	for _, line := range lines {
+		_, _ = r.out.WriteString(line)
+		_, _ = r.out.WriteString("\n")
+	}
+	_ = r.out.Flush()
+}
+
+// appendSummary appends the title of the scope followed by its descendants that failed and its output
+// if the scope failed itself.
+func (r *FullScreenRenderer) appendSummary(lines []string, snapshot *node.Snapshot, indent string, width int) []string {
+	lines = append(lines, indent+snapshot.RenderTitle(width-len(indent)))
+	tailIndent := indent + "   "
+	for _, child := range snapshot.GetChildren() {
+		if r.hasFailures(child) {
+			lines = r.appendSummary(lines, child, tailIndent, width)
+		}
+	}
+	if r.isFailed(snapshot) {
+		description := snapshot.VisibleDescription()
+		if !r.showDebug {
+			description = snapshot.VisibleDescriptionWithoutVerbose()
+		}
+		if last := len(description) - 1; last >= 0 && description[last] == "" {
+			// output ending with a line break
+			description = description[:last]
+		}
+		for _, line := range description {
+			lines = append(lines, tailIndent+terminal.Truncate(line, width-len(tailIndent), terminal.Ellipsis))
+		}
+	}
+	return lines
+}
+
+func (r *FullScreenRenderer) hasFailures(snapshot *node.Snapshot) bool {
+	if r.isFailed(snapshot) {
+		return true
+	}
+	for _, child := range snapshot.GetChildren() {
+		if r.hasFailures(child) {
+			return true
+		}
+	}
+	return false
+}
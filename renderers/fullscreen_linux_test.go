@@ -0,0 +1,159 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// openPty returns both ends of a new pseudo-terminal of the given size.
+func openPty(t *testing.T, width int, height int) (*os.File, *os.File) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	t.Cleanup(func() { _ = master.Close() })
+	require.NoError(t, unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0))
+	number, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	require.NoError(t, err)
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", number), os.O_RDWR|unix.O_NOCTTY, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = slave.Close() })
+	require.NoError(t, unix.IoctlSetWinsize(int(slave.Fd()), unix.TIOCSWINSZ, &unix.Winsize{
+		Col: uint16(width),
+		Row: uint16(height),
+	}))
+	return master, slave
+}
+
+func Test_FullScreenRenderer_Pty(t *testing.T) {
+	t.Parallel()
+	master, slave := openPty(t, 60, 10)
+	termiosBefore, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	require.NoError(t, err)
+	var screen screenRecorder
+	go func() {
+		_, _ = io.Copy(&screen, master)
+	}()
+
+	renderer := NewFullScreenRenderer(slave, slave, config.NewDefaultSymbolsOnlyRenderingConfig())
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("build"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "build"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("test"))
+	renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"test"}, echelon.InfoLevel, "assertion failed"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "test"))
+	drawing := make(chan struct{})
+	go func() {
+		renderer.StartDrawing()
+		close(drawing)
+	}()
+	require.Eventually(t, func() bool {
+		return strings.Contains(screen.String(), "n next failure")
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, screen.String(), "\x1B[?1049h")
+
+	_, err = master.WriteString("n\r")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return strings.Contains(screen.String(), "assertion failed")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	renderer.StopDrawing()
+	<-drawing
+	require.Eventually(t, func() bool {
+		output := screen.String()
+		leave := strings.LastIndex(output, "\x1B[?1049l")
+		return leave >= 0 && strings.Contains(output[leave:], "assertion failed")
+	}, 5*time.Second, 10*time.Millisecond)
+	termiosAfter, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	require.NoError(t, err)
+	assert.Equal(t, termiosBefore.Lflag, termiosAfter.Lflag)
+	assert.Equal(t, termiosBefore.Iflag, termiosAfter.Iflag)
+
+	// the goroutine reading key presses is gone, so input typed now is left for the application
+	_, err = master.WriteString("after\n")
+	require.NoError(t, err)
+	read := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := slave.Read(buf)
+		read <- string(buf[:n])
+	}()
+	select {
+	case line := <-read:
+		assert.Equal(t, "after\n", line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the input written after StopDrawing is not readable")
+	}
+}
+
+func Test_FullScreenRenderer_SuspendAndResume(t *testing.T) {
+	t.Parallel()
+	master, slave := openPty(t, 60, 10)
+	termiosBefore, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	require.NoError(t, err)
+	var screen screenRecorder
+	go func() {
+		_, _ = io.Copy(&screen, master)
+	}()
+
+	renderer := NewFullScreenRenderer(slave, slave, config.NewDefaultSymbolsOnlyRenderingConfig())
+	assert.Implements(t, (*echelon.SuspendableRenderer)(nil), renderer)
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("build"))
+	drawing := make(chan struct{})
+	go func() {
+		renderer.StartDrawing()
+		close(drawing)
+	}()
+	require.Eventually(t, func() bool {
+		return strings.Contains(screen.String(), "n next failure")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the subprocess gets the primary screen and the line discipline back
+	renderer.Suspend()
+	require.Eventually(t, func() bool {
+		return strings.Contains(screen.String(), "\x1B[?1049l")
+	}, 5*time.Second, 10*time.Millisecond)
+	termiosSuspended, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	require.NoError(t, err)
+	assert.Equal(t, termiosBefore.Lflag, termiosSuspended.Lflag)
+	_, err = master.WriteString("answer\n")
+	require.NoError(t, err)
+	read := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := slave.Read(buf)
+		read <- string(buf[:n])
+	}()
+	select {
+	case line := <-read:
+		assert.Equal(t, "answer\n", line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the input written while suspended is not readable")
+	}
+
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "build"))
+	suspendedOutput := len(screen.String())
+	renderer.Resume()
+	require.Eventually(t, func() bool {
+		output := screen.String()[suspendedOutput:]
+		return strings.Contains(output, "\x1B[?1049h") && strings.Contains(output, "n next failure")
+	}, 5*time.Second, 10*time.Millisecond)
+	termiosResumed, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	require.NoError(t, err)
+	assert.NotEqual(t, termiosBefore.Lflag, termiosResumed.Lflag)
+
+	renderer.StopDrawing()
+	<-drawing
+}
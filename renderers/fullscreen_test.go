@@ -0,0 +1,142 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseKeys(t *testing.T) {
+	t.Parallel()
+	keys, rest := parseKeys([]byte("j\x1B[Ak\x1B[5~x\x1B[2;5Dnd \x1B["))
+	assert.Equal(t, []key{keyDown, keyUp, keyUp, keyPageUp, keyNextFailure, keyToggleDebug, keyToggle}, keys)
+	assert.Equal(t, "\x1B[", string(rest))
+	keys, rest = parseKeys(append(rest, 'B'))
+	assert.Equal(t, []key{keyDown}, keys)
+	assert.Empty(t, rest)
+}
+
+func Test_FullScreenRenderer_Navigation(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	renderer := NewFullScreenRenderer(out, out, config.NewDefaultSymbolsOnlyRenderingConfig())
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("build"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("build", "compile"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "build", "compile"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("build", "test"))
+	renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"build", "test"}, echelon.InfoLevel, "assertion failed"))
+	renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"build", "test"}, echelon.DebugLevel, "details"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "build", "test"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "build"))
+
+	renderer.handleKeys([]key{keyNextFailure})
+	assert.Equal(t, []string{"build", "test"}, renderer.selected)
+	renderer.handleKeys([]key{keyNextFailure})
+	assert.Equal(t, []string{"build"}, renderer.selected)
+	renderer.handleKeys([]key{keyNextFailure, keyToggle})
+	assert.Equal(t, []string{"build", "test"}, renderer.selected)
+	screen := renderer.renderScreen()
+	assert.Len(t, screen, defaultScreenHeight)
+	assert.Contains(t, screen[2], "> ")
+	assert.Equal(t, "        assertion failed", screen[3])
+	assert.NotContains(t, strings.Join(screen, "\n"), "details")
+
+	renderer.handleKeys([]key{keyToggleDebug, keyUp})
+	assert.Equal(t, []string{"build", "compile"}, renderer.selected)
+	assert.Contains(t, strings.Join(renderer.renderScreen(), "\n"), "details")
+
+	renderer.handleKeys([]key{keyToggleDebug, keyHome})
+	renderer.printSummary()
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	summary := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, summary, 3)
+	assert.Contains(t, summary[0], "build")
+	assert.Contains(t, summary[1], "test")
+	assert.Equal(t, "      assertion failed", summary[2])
+}
+
+func Test_FullScreenRenderer_ForWriter(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.RetentionWhenSucceeded = echelon.RetentionPolicy{Mode: echelon.CollapseToTitle}
+	renderer := NewFullScreenRendererForWriter(nil, &out, NewFixedSizeProvider(40, 5), rendererConfig)
+	renderer.RenderRetention(echelon.NewLogScopeRetention(echelon.FinishTypeSucceeded,
+		echelon.RetentionPolicy{Mode: echelon.RemoveScope}, "lint"))
+	for _, scope := range []string{"lint", "build", "test"} {
+		renderer.RenderScopeStarted(echelon.NewLogScopeStarted(scope))
+		renderer.RenderMessage(echelon.NewLogEntryMessage([]string{scope}, echelon.InfoLevel, scope+" output"))
+	}
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "lint"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "build"))
+
+	screen := renderer.renderScreen()
+	assert.Len(t, screen, 5)
+	// lint is removed and the output of build is dropped as the policies say, test is still running
+	require.NotNil(t, renderer.latestSnapshot().FindChild("build"))
+	assert.Nil(t, renderer.latestSnapshot().FindChild("lint"))
+	renderer.handleKeys([]key{keyEnd, keyExpand})
+	assert.Equal(t, []string{"test"}, renderer.selected)
+	screen = renderer.renderScreen()
+	assert.NotContains(t, strings.Join(screen, "\n"), "build output")
+	assert.Contains(t, strings.Join(screen, "\n"), "test output")
+
+	// the output isn't read again until it changes
+	cached := renderer.outputs["test"]
+	renderer.renderScreen()
+	assert.Same(t, cached.snapshot, renderer.outputs["test"].snapshot)
+	renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"test"}, echelon.InfoLevel, "more output"))
+	assert.Contains(t, strings.Join(renderer.renderScreen(), "\n"), "more output")
+	assert.NotSame(t, cached.snapshot, renderer.outputs["test"].snapshot)
+	renderer.handleKeys([]key{keyCollapse})
+	assert.Empty(t, renderer.outputs)
+}
+
+func Test_FullScreenRenderer_StopDrawingRemovesSpilledOutput(t *testing.T) {
+	t.Parallel()
+	for _, keep := range []bool{false, true} {
+		rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+		rendererConfig.MaxDescriptionLines = 2
+		rendererConfig.SpillDescriptionToDisk = true
+		rendererConfig.SpillDirectory = t.TempDir()
+		rendererConfig.KeepSpilledOutput = keep
+		var out bytes.Buffer
+		renderer := NewFullScreenRendererForWriter(nil, &out, NewFixedSizeProvider(40, 5), rendererConfig)
+		renderer.RenderScopeStarted(echelon.NewLogScopeStarted("build"))
+		renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"build"}, echelon.InfoLevel, "one\ntwo"))
+		renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"build"}, echelon.DebugLevel, "details"))
+		renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"build"}, echelon.InfoLevel, "three\nfour"))
+		renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "build"))
+		files, err := filepath.Glob(filepath.Join(rendererConfig.SpillDirectory, "*"))
+		require.NoError(t, err)
+		require.Len(t, files, 1, "debug messages are kept in the same tree")
+
+		renderer.StopDrawing()
+		assert.Contains(t, out.String(), "four")
+		files, err = filepath.Glob(filepath.Join(rendererConfig.SpillDirectory, "*"))
+		require.NoError(t, err)
+		if !keep {
+			assert.Empty(t, files)
+			continue
+		}
+		assert.Len(t, files, 1)
+		var output bytes.Buffer
+		require.NoError(t, renderer.WriteScopeOutput(&output, "build"))
+		assert.Equal(t, "one\ntwo\ndetails\nthree\nfour\n", output.String())
+		renderer.ReleaseOutput()
+		files, err = filepath.Glob(filepath.Join(rendererConfig.SpillDirectory, "*"))
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	}
+}
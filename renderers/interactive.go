@@ -2,45 +2,154 @@ package renderers
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
 	"github.com/cirruslabs/echelon"
 	"github.com/cirruslabs/echelon/renderers/config"
 	"github.com/cirruslabs/echelon/renderers/internal/console"
 	"github.com/cirruslabs/echelon/renderers/internal/node"
 	"github.com/cirruslabs/echelon/terminal"
+	"io"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const disableAutoWrap = "\u001B[?7l"
 const enableAutoWrap = "\u001B[?7h"
+const hideCursor = "\u001B[?25l"
+const showCursor = "\u001B[?25h"
+const beginSynchronizedUpdate = "\u001B[?2026h"
+const endSynchronizedUpdate = "\u001B[?2026l"
 const defaultFrameBufSize = 38400 // 80 by 120 of 4 bytes UTF-8 characters
 
+var ErrScopeNotFound = errors.New("scope not found")
+
+// flushLatencyFactor keeps slow outputs (e.g. over SSH) busy with frames for at most a quarter of the time.
+const flushLatencyFactor = 4
+
+// InteractiveRenderer keeps the tree of scopes which is modified by the goroutine delivering events and by StopDrawing
+// completing the root. The drawing loop renders immutable snapshots of the tree published after each modification.
 type InteractiveRenderer struct {
+	output            io.Writer
+	sizeProvider      SizeProvider
 	out               *bufio.Writer
+	frame             bytes.Buffer
+	frameWriter       *bufio.Writer
+	synchronizeFrames bool
+	terminalPrepared  bool
+	restored          bool // by Restore, nothing is drawn anymore
 	rootNode          *node.EchelonNode
+	treeLock          sync.Mutex // guards the tree against StopDrawing completing the root
+	snapshot          atomic.Value
+	stopped           int32
 	config            *config.InteractiveRendererConfig
 	currentFrameLines []string
+	committedScopes   map[uint64]bool // IDs of top-level scopes and members of groups printed to the scrollback
+	retentions        retentionOverrides
+	pendingOutput     []byte   // incomplete line written via Write
+	suspensions       int      // nested Suspend calls without a matching Resume
+	suspendedOutput   []string // lines written via Write while suspended
 	drawLock          sync.Mutex
 	terminalHeight    int
+	terminalWidth     int
+	resized           int32
+	dirty             chan struct{}
+	flushLatency      time.Duration
 
 	StubRenderer
 }
 
+// retentionKey identifies a retention policy set via Logger.SetRetention.
+type retentionKey struct {
+	scope      string
+	finishType echelon.FinishType
+}
+
+// retentionOverrides keeps the policies set via Logger.SetRetention until their scopes finish.
+type retentionOverrides map[retentionKey]echelon.RetentionPolicy
+
+func (overrides retentionOverrides) set(entry *echelon.LogScopeRetention) {
+	overrides[retentionKey{scope: scopeKey(entry.GetScopes()), finishType: entry.FinishType()}] = entry.Policy()
+}
+
+// take returns the policy set for the scope finishing with the finish type. A scope finishes once, so all of its
+// policies are forgotten.
+func (overrides retentionOverrides) take(
+	scopes []string,
+	finishType echelon.FinishType,
+) (echelon.RetentionPolicy, bool) {
+	scope := scopeKey(scopes)
+	policy, ok := overrides[retentionKey{scope: scope, finishType: finishType}]
+	delete(overrides, retentionKey{scope: scope, finishType: echelon.FinishTypeSucceeded})
+	delete(overrides, retentionKey{scope: scope, finishType: echelon.FinishTypeFailed})
+	delete(overrides, retentionKey{scope: scope, finishType: echelon.FinishTypeSkipped})
+	return policy, ok && policy.Mode != echelon.RetentionDefault
+}
+
 func NewInteractiveRenderer(out *os.File, rendererConfig *config.InteractiveRendererConfig) *InteractiveRenderer {
+	return NewInteractiveRendererForWriter(out, NewFileSizeProvider(out), rendererConfig)
+}
+
+// NewInteractiveRendererForWriter draws to any writer connected to a terminal, e.g. a channel of an SSH session,
+// with the size of the terminal reported by the provider. Nil provider means the size of the file if out is a file
+// and an unknown size otherwise.
+func NewInteractiveRendererForWriter(
+	out io.Writer,
+	sizeProvider SizeProvider,
+	rendererConfig *config.InteractiveRendererConfig,
+) *InteractiveRenderer {
 	if rendererConfig == nil {
 		rendererConfig = config.NewDefaultRenderingConfig()
 	}
-	return &InteractiveRenderer{
-		out:            bufio.NewWriterSize(out, defaultFrameBufSize),
-		rootNode:       node.NewEchelonNode("root", rendererConfig),
-		config:         rendererConfig,
-		terminalHeight: console.TerminalHeight(out),
+	if sizeProvider == nil {
+		if file, ok := out.(*os.File); ok {
+			sizeProvider = NewFileSizeProvider(file)
+		} else {
+			sizeProvider = NewFixedSizeProvider(0, 0)
+		}
+	}
+	terminalWidth, terminalHeight := sizeProvider.Size()
+	result := &InteractiveRenderer{
+		output:          out,
+		sizeProvider:    sizeProvider,
+		out:             bufio.NewWriterSize(out, defaultFrameBufSize),
+		rootNode:        node.NewEchelonNode("root", rendererConfig),
+		config:          rendererConfig,
+		committedScopes: make(map[uint64]bool),
+		retentions:      make(retentionOverrides),
+		terminalHeight:  terminalHeight,
+		terminalWidth:   terminalWidth,
+		dirty:           make(chan struct{}, 1),
 	}
+	result.frameWriter = bufio.NewWriterSize(&result.frame, defaultFrameBufSize)
+	switch rendererConfig.SynchronizedOutput {
+	case config.FeatureEnabled:
+		result.synchronizeFrames = true
+	case config.FeatureDisabled:
+		result.synchronizeFrames = false
+	default:
+		result.synchronizeFrames = terminal.SupportsSynchronizedOutput(os.Getenv)
+	}
+	result.snapshot.Store(result.rootNode.Snapshot())
+	return result
 }
 
 func findScopedNode(scopes []string, r *InteractiveRenderer) *node.EchelonNode {
-	result := r.rootNode
+	return findChildNode(r.rootNode, scopes)
+}
+
+// scopeKey identifies the scope in maps. Names of scopes might contain slashes, so they're joined with NUL characters
+// which don't show up in names in practice.
+func scopeKey(scopes []string) string {
+	return strings.Join(scopes, "\x00")
+}
+
+func findChildNode(root *node.EchelonNode, scopes []string) *node.EchelonNode {
+	result := root
 	for _, scope := range scopes {
 		result = result.FindOrCreateChild(scope)
 	}
@@ -48,67 +157,495 @@ func findScopedNode(scopes []string, r *InteractiveRenderer) *node.EchelonNode {
 }
 
 func (r *InteractiveRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	r.treeLock.Lock()
+	defer r.treeLock.Unlock()
 	findScopedNode(entry.GetScopes(), r).Start()
+	r.publish()
 }
 
 func (r *InteractiveRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
-	n := findScopedNode(entry.GetScopes(), r)
+	r.treeLock.Lock()
+	defer r.treeLock.Unlock()
+	r.finishNode(entry.GetScopes(), findScopedNode(entry.GetScopes(), r), entry.FinishType())
+	r.publish()
+}
 
-	switch entry.FinishType() {
-	case echelon.FinishTypeSucceeded:
+func (r *InteractiveRenderer) RenderRetention(entry *echelon.LogScopeRetention) {
+	r.treeLock.Lock()
+	defer r.treeLock.Unlock()
+	r.retentions.set(entry)
+}
+
+// retention returns the policy for the scope set via Logger.SetRetention or the configured one.
+func (r *InteractiveRenderer) retention(scopes []string, finishType echelon.FinishType) echelon.RetentionPolicy {
+	if policy, ok := r.retentions.take(scopes, finishType); ok {
+		return policy
+	}
+	return r.config.Retention(finishType)
+}
+
+func (r *InteractiveRenderer) finishNode(scopes []string, n *node.EchelonNode, finishType echelon.FinishType) {
+	policy := r.retention(scopes, finishType)
+	switch policy.Mode {
+	case echelon.RetainChildren:
+		n.SetVisibleDescriptionLines(policy.Lines)
+	case echelon.RetainDescription:
 		if n != r.rootNode {
 			n.ClearAllChildren()
-			n.ClearDescription()
 		}
-		n.CompleteWithColor(r.config.SuccessStatus, r.config.Colors.SuccessColor)
-	case echelon.FinishTypeFailed:
-		n.SetVisibleDescriptionLines(r.config.DescriptionLinesWhenFailed)
-		n.CompleteWithColor(r.config.FailureStatus, r.config.Colors.FailureColor)
-	case echelon.FinishTypeSkipped:
-		if r.config.DescriptionLinesWhenSkipped != 0 {
-			n.SetVisibleDescriptionLines(r.config.DescriptionLinesWhenSkipped)
-		} else if n != r.rootNode {
+		n.SetVisibleDescriptionLines(policy.Lines)
+	case echelon.CollapseToTitle, echelon.RemoveScope, echelon.RetentionDefault:
+		if n != r.rootNode {
 			n.ClearAllChildren()
 			n.ClearDescription()
 		}
-		n.CompleteWithColor(r.config.SkippedStatus, r.config.Colors.NeutralColor)
+	}
+	switch finishType {
+	case echelon.FinishTypeSucceeded:
+		n.CompleteWithColor(r.config.SuccessStatus, r.config.ColorSchema().SuccessColor)
+	case echelon.FinishTypeFailed:
+		n.CompleteWithColor(r.config.FailureStatus, r.config.ColorSchema().FailureColor)
+	case echelon.FinishTypeSkipped:
+		n.CompleteWithColor(r.config.SkippedStatus, r.config.ColorSchema().NeutralColor)
+	}
+	if policy.Mode == echelon.RemoveScope && n != r.rootNode {
+		n.Remove()
 	}
 }
 
 func (r *InteractiveRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
-	findScopedNode(entry.GetScopes(), r).AppendDescription(entry.GetMessage())
+	r.treeLock.Lock()
+	defer r.treeLock.Unlock()
+	r.appendMessage(findScopedNode(entry.GetScopes(), r), entry)
+	r.publish()
+}
+
+// appendMessage appends the message styled by its level to the output of the node and counts lines of warnings
+// and errors.
+func (r *InteractiveRenderer) appendMessage(n *node.EchelonNode, entry *echelon.LogEntryMessage) {
+	message := entry.GetMessage()
+	n.AppendDescription(r.config.PaintMessage(entry.Level, message))
+	switch entry.Level {
+	case echelon.WarnLevel:
+		n.CountMessages(strings.Count(message, "\n"), 0)
+	case echelon.ErrorLevel:
+		n.CountMessages(0, strings.Count(message, "\n"))
+	}
+}
+
+func (r *InteractiveRenderer) RenderBadge(entry *echelon.LogScopeBadge) {
+	r.treeLock.Lock()
+	defer r.treeLock.Unlock()
+	findScopedNode(entry.GetScopes(), r).SetBadge(entry.Name(), entry.Text())
+	r.publish()
+}
+
+// RenderBatch applies a batch of events while resolving each scope's node only once.
+func (r *InteractiveRenderer) RenderBatch(events []*echelon.Event) {
+	r.treeLock.Lock()
+	defer r.treeLock.Unlock()
+	nodes := make(map[string]*node.EchelonNode)
+	lookup := func(scopes []string) *node.EchelonNode {
+		key := scopeKey(scopes)
+		if n, ok := nodes[key]; ok {
+			return n
+		}
+		n := findScopedNode(scopes, r)
+		nodes[key] = n
+		return n
+	}
+	for _, event := range events {
+		if event.LogStarted != nil {
+			lookup(event.LogStarted.GetScopes()).Start()
+		}
+		if event.LogFinished != nil {
+			scopes := event.LogFinished.GetScopes()
+			r.finishNode(scopes, lookup(scopes), event.LogFinished.FinishType())
+			// finishing might have detached some of the children
+			nodes = make(map[string]*node.EchelonNode)
+		}
+		if event.LogEntry != nil {
+			r.appendMessage(lookup(event.LogEntry.GetScopes()), event.LogEntry)
+		}
+		if event.LogBadge != nil {
+			lookup(event.LogBadge.GetScopes()).SetBadge(event.LogBadge.Name(), event.LogBadge.Text())
+		}
+		if event.LogRetention != nil {
+			r.retentions.set(event.LogRetention)
+		}
+	}
+	r.publish()
+}
+
+// publish makes the current state of the tree available for drawing.
+func (r *InteractiveRenderer) publish() {
+	r.snapshot.Store(r.rootNode.Snapshot())
+	r.markDirty()
+}
+
+func (r *InteractiveRenderer) latestSnapshot() *node.Snapshot {
+	return r.snapshot.Load().(*node.Snapshot)
+}
+
+// markDirty wakes up the drawing loop without blocking if it's already scheduled to draw.
+func (r *InteractiveRenderer) markDirty() {
+	select {
+	case r.dirty <- struct{}{}:
+	default:
+	}
+}
+
+// WriteScopeOutput writes the whole output of a scope including lines that were spilled to disk.
+// It can be called from any goroutine.
+func (r *InteractiveRenderer) WriteScopeOutput(w io.Writer, scopes ...string) error {
+	snapshot := r.latestSnapshot().FindChild(scopes...)
+	if snapshot == nil {
+		return fmt.Errorf("%w: %s", ErrScopeNotFound, strings.Join(scopes, "/"))
+	}
+	return snapshot.WriteFullDescription(w)
+}
+
+// ReleaseOutput removes temporary files with spilled output of all scopes. StopDrawing calls it unless
+// KeepSpilledOutput is set, then call it once the full output is no longer needed, e.g. after reporting failures.
+func (r *InteractiveRenderer) ReleaseOutput() {
+	r.latestSnapshot().ReleaseOutput()
 }
 
 func (r *InteractiveRenderer) StartDrawing() {
 	_ = console.PrepareTerminalEnvironment()
-	// don't wrap lines since it breaks incremental redraws
-	_, _ = r.out.WriteString(disableAutoWrap)
-	for !r.rootNode.HasCompleted() {
+	r.prepareTerminal()
+	// don't leave the cursor hidden or autowrap disabled if the process is about to die
+	stopHandling := handleTermination(r.config.TerminationSignals, r.Restore)
+	defer stopHandling()
+	resizes := make(chan struct{}, 1)
+	r.sizeProvider.NotifyResize(resizes)
+	defer func() {
+		r.sizeProvider.StopNotifyResize(resizes)
+		close(resizes)
+	}()
+	go func() {
+		for range resizes {
+			atomic.StoreInt32(&r.resized, 1)
+			r.markDirty()
+		}
+	}()
+	for !r.isDone() {
+		frameStart := time.Now()
 		r.DrawFrame()
-		time.Sleep(r.config.RefreshRate)
+		time.Sleep(r.frameInterval() - time.Since(frameStart))
+		r.waitForChanges()
+	}
+}
+
+func (r *InteractiveRenderer) prepareTerminal() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	if r.suspensions == 0 {
+		r.writeTerminalModes(true)
 	}
+	r.terminalPrepared = true
 }
 
+// handleTermination calls restore once the process receives a termination signal until the returned function
+// is called.
+func handleTermination(handling config.SignalHandling, restore func()) func() {
+	if handling == config.SignalsIgnore {
+		return func() {}
+	}
+	terminations := make(chan os.Signal, 1)
+	console.NotifyTermination(terminations)
+	go func() {
+		for sig := range terminations {
+			restore()
+			if handling == config.SignalsRestoreAndExit {
+				console.StopNotifyTermination(terminations)
+				console.Reraise(sig)
+			}
+		}
+	}()
+	return func() {
+		console.StopNotifyTermination(terminations)
+		close(terminations)
+	}
+}
+
+func (r *InteractiveRenderer) restoreTerminal() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	if !r.terminalPrepared {
+		return
+	}
+	if r.suspensions == 0 {
+		r.writeTerminalModes(false)
+	}
+	r.terminalPrepared = false
+}
+
+// writeTerminalModes switches the terminal modes needed for drawing on or back to the defaults.
+func (r *InteractiveRenderer) writeTerminalModes(drawing bool) {
+	if drawing {
+		// don't wrap lines since it breaks incremental redraws
+		_, _ = r.out.WriteString(disableAutoWrap)
+		if r.config.HideCursor {
+			_, _ = r.out.WriteString(hideCursor)
+		}
+	} else {
+		// don't leave autowrap disabled in the terminal
+		_, _ = r.out.WriteString(enableAutoWrap)
+		if r.config.HideCursor {
+			_, _ = r.out.WriteString(showCursor)
+		}
+	}
+	_ = r.out.Flush()
+}
+
+// Suspend erases the frame and hands the terminal over, e.g. to an interactive subprocess. Events received
+// while suspended still update the scopes but nothing is drawn until the matching Resume.
+// Calls can be nested.
+func (r *InteractiveRenderer) Suspend() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	r.suspensions++
+	if r.suspensions > 1 {
+		return
+	}
+	r.frame.Reset()
+	terminal.Repaint(r.frameWriter, r.linesOnScreen(r.terminalHeight), nil, 0)
+	r.currentFrameLines = nil
+	r.writeFrame()
+	if r.terminalPrepared {
+		r.writeTerminalModes(false)
+	}
+}
+
+// Resume takes the terminal back after Suspend, prints output written in the meantime and redraws the frame
+// from scratch.
+func (r *InteractiveRenderer) Resume() {
+	r.drawLock.Lock()
+	if r.suspensions == 0 || r.suspensions > 1 {
+		if r.suspensions > 0 {
+			r.suspensions--
+		}
+		r.drawLock.Unlock()
+		return
+	}
+	r.suspensions = 0
+	if r.terminalPrepared {
+		r.writeTerminalModes(true)
+	}
+	if len(r.suspendedOutput) > 0 {
+		r.printAboveFrame(r.suspendedOutput)
+		r.suspendedOutput = nil
+	}
+	r.drawLock.Unlock()
+	r.DrawFrame()
+}
+
+func (r *InteractiveRenderer) isDone() bool {
+	return atomic.LoadInt32(&r.stopped) != 0 || r.latestSnapshot().HasCompleted()
+}
+
+// frameInterval is the minimal time between two frames adjusted for how slow the output is.
+func (r *InteractiveRenderer) frameInterval() time.Duration {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	result := r.config.MinFrameInterval()
+	if adaptive := flushLatencyFactor * r.flushLatency; adaptive > result {
+		return adaptive
+	}
+	return result
+}
+
+// waitForChanges blocks until an event changed the tree or a running scope needs its progress redrawn.
+func (r *InteractiveRenderer) waitForChanges() {
+	if !r.latestSnapshot().HasRunningNodes() {
+		<-r.dirty
+		return
+	}
+	tick := time.NewTimer(r.config.AnimationTickInterval())
+	defer tick.Stop()
+	select {
+	case <-r.dirty:
+	case <-tick.C:
+	}
+}
+
+// StopDrawing completes the root scope if the logger didn't finish, draws the last frame, restores the terminal
+// and removes the temporary files with spilled output unless KeepSpilledOutput is set.
 func (r *InteractiveRenderer) StopDrawing() {
+	r.treeLock.Lock()
 	r.rootNode.Complete()
+	r.publish()
+	r.treeLock.Unlock()
+	atomic.StoreInt32(&r.stopped, 1)
+	// wake up the drawing loop so it can exit
+	r.markDirty()
 	// one last redraw
+	r.flushPendingOutput()
 	r.DrawFrame()
-	// don't leave autowrap disabled in the terminal
-	_, _ = r.out.WriteString(enableAutoWrap)
-	_ = r.out.Flush()
+	r.restoreTerminal()
+	if !r.config.KeepSpilledOutput {
+		r.ReleaseOutput()
+	}
+}
+
+// Restore stops drawing and restores the terminal right away without drawing the last frame, e.g. from
+// a termination signal handler of the application. Nothing is drawn afterwards.
+func (r *InteractiveRenderer) Restore() {
+	atomic.StoreInt32(&r.stopped, 1)
+	r.markDirty()
+	r.drawLock.Lock()
+	r.restored = true
+	r.drawLock.Unlock()
+	r.restoreTerminal()
 }
 
 func (r *InteractiveRenderer) DrawFrame() {
 	r.drawLock.Lock()
 	defer r.drawLock.Unlock()
-	var newFrameLines []string
-	for _, n := range r.rootNode.GetChildren() {
-		newFrameLines = append(newFrameLines, n.Render()...)
+	if r.suspensions > 0 || r.restored {
+		return
 	}
-	if r.terminalHeight > 0 {
-		terminal.CalculateIncrementalUpdateMaxLines(r.out, r.currentFrameLines, newFrameLines, r.terminalHeight)
-	} else {
-		terminal.CalculateIncrementalUpdate(r.out, r.currentFrameLines, newFrameLines)
+	previousHeight := r.terminalHeight
+	sizeChanged := false
+	if atomic.SwapInt32(&r.resized, 0) != 0 {
+		width, height := r.sizeProvider.Size()
+		sizeChanged = width != r.terminalWidth || height != r.terminalHeight
+		r.terminalWidth, r.terminalHeight = width, height
+	}
+	committedLines, newFrameLines := r.renderTopLevelScopes()
+	linesOnScreen := r.linesOnScreen(previousHeight)
+	r.frame.Reset()
+	switch {
+	case sizeChanged:
+		// lines on the screen might have been cut or reflowed so incremental updates are no longer reliable
+		terminal.Commit(r.frameWriter, linesOnScreen, committedLines, newFrameLines, r.terminalHeight)
+	case r.terminalHeight > 0 && len(committedLines) > 0 && len(committedLines)+len(newFrameLines) > r.terminalHeight:
+		// incremental updates would cut committed lines that don't fit on the screen
+		terminal.Commit(r.frameWriter, linesOnScreen, committedLines, newFrameLines, r.terminalHeight)
+	case r.terminalHeight > 0:
+		terminal.CalculateIncrementalUpdateMaxLines(r.frameWriter, r.currentFrameLines,
+			append(committedLines, newFrameLines...), r.terminalHeight)
+	default:
+		terminal.CalculateIncrementalUpdate(r.frameWriter, r.currentFrameLines, append(committedLines, newFrameLines...))
 	}
 	r.currentFrameLines = newFrameLines
+	r.writeFrame()
+	// titles fall back to the default ones, so the errors of the title template are reported once above the frame
+	if errs := r.rootNode.TakeTemplateErrors(); len(errs) > 0 {
+		lines := make([]string, 0, len(errs))
+		for _, err := range errs {
+			lines = append(lines, err.Error())
+		}
+		r.printAboveFrame(lines)
+	}
+}
+
+// linesOnScreen returns how many lines of the current frame fit on the screen of the given height.
+func (r *InteractiveRenderer) linesOnScreen(height int) int {
+	if height > 0 && len(r.currentFrameLines) > height {
+		return height
+	}
+	return len(r.currentFrameLines)
+}
+
+// Write prints complete lines of text permanently above the frame, the last incomplete line is kept until
+// it's completed or the drawing is stopped. Use it for any output that doesn't go through the logger,
+// otherwise it will corrupt the frame.
+func (r *InteractiveRenderer) Write(p []byte) (int, error) {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	r.pendingOutput = append(r.pendingOutput, p...)
+	lastLineBreak := bytes.LastIndexByte(r.pendingOutput, '\n')
+	if lastLineBreak < 0 {
+		return len(p), nil
+	}
+	lines := strings.Split(string(r.pendingOutput[:lastLineBreak]), "\n")
+	r.pendingOutput = append([]byte(nil), r.pendingOutput[lastLineBreak+1:]...)
+	r.printAboveFrame(lines)
+	return len(p), nil
+}
+
+// Println formats the operands like fmt.Println and prints them above the frame.
+func (r *InteractiveRenderer) Println(a ...interface{}) {
+	_, _ = fmt.Fprintln(r, a...)
+}
+
+// printAboveFrame commits the lines to the scrollback and redraws the current frame below them.
+func (r *InteractiveRenderer) printAboveFrame(lines []string) {
+	if r.suspensions > 0 {
+		r.suspendedOutput = append(r.suspendedOutput, lines...)
+		return
+	}
+	if r.restored {
+		// the frame is left as is, so the lines are simply printed below it
+		_, _ = r.out.WriteString(strings.Join(lines, "\n") + "\n")
+		_ = r.out.Flush()
+		return
+	}
+	r.frame.Reset()
+	terminal.Commit(r.frameWriter, r.linesOnScreen(r.terminalHeight), lines, r.currentFrameLines, r.terminalHeight)
+	r.writeFrame()
+}
+
+// flushPendingOutput prints the incomplete line written via Write if there is one.
+func (r *InteractiveRenderer) flushPendingOutput() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	if len(r.pendingOutput) == 0 {
+		return
+	}
+	r.printAboveFrame([]string{string(r.pendingOutput)})
+	r.pendingOutput = nil
+}
+
+// renderTopLevelScopes returns lines of top-level scopes that just finished and should be printed to the scrollback
+// once and lines of the rest of the scopes which are redrawn on every frame.
+func (r *InteractiveRenderer) renderTopLevelScopes() ([]string, []string) {
+	var committedLines []string
+	var frameScopes []*node.Snapshot
+	root := r.latestSnapshot()
+	columns := root.ChildColumnWidths()
+	committed := func(id uint64) bool {
+		return r.committedScopes[id]
+	}
+	for _, n := range root.GetChildren() {
+		// groups that gained members after they were committed show only the new ones
+		n = n.WithoutMembers(committed)
+		if n == nil || r.committedScopes[n.ID()] {
+			continue
+		}
+		if r.config.CommitFinishedScopes && n.HasCompleted() && !n.HasRunningNodes() {
+			r.committedScopes[n.ID()] = true
+			for _, id := range n.MemberIDs() {
+				r.committedScopes[id] = true
+			}
+			committedLines = append(committedLines, n.RenderAligned(r.terminalWidth, columns)...)
+			continue
+		}
+		frameScopes = append(frameScopes, n)
+	}
+	maxLines := 0
+	if r.config.FitToScreen {
+		maxLines = r.terminalHeight
+	}
+	return committedLines, node.RenderWithin(frameScopes, r.terminalWidth, columns, maxLines)
+}
+
+// writeFrame writes the prepared frame at once so the terminal doesn't show intermediate states.
+func (r *InteractiveRenderer) writeFrame() {
+	if r.frame.Len() == 0 {
+		return
+	}
+	flushStart := time.Now()
+	if r.synchronizeFrames {
+		_, _ = r.out.WriteString(beginSynchronizedUpdate)
+	}
+	_, _ = r.out.Write(r.frame.Bytes())
+	if r.synchronizeFrames {
+		_, _ = r.out.WriteString(endSynchronizedUpdate)
+	}
+	_ = r.out.Flush()
+	// smooth out the measurements so a single hiccup doesn't drop the frame rate
+	r.flushLatency = (3*r.flushLatency + time.Since(flushStart)) / 4
 }
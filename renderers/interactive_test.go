@@ -0,0 +1,557 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unbatchedRenderer hides RenderBatch so the logger falls back to one call per event.
+type unbatchedRenderer struct {
+	renderer *InteractiveRenderer
+}
+
+func (u *unbatchedRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	u.renderer.RenderScopeStarted(entry)
+}
+
+func (u *unbatchedRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+	u.renderer.RenderScopeFinished(entry)
+}
+
+func (u *unbatchedRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+	u.renderer.RenderMessage(entry)
+}
+
+func newDevNullInteractiveRenderer(b *testing.B) *InteractiveRenderer {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = devNull.Close() })
+	return NewInteractiveRenderer(devNull, nil)
+}
+
+func benchmarkChattyScope(b *testing.B, renderer *InteractiveRenderer, logRenderer echelon.LogRendered) {
+	logger := echelon.NewLogger(echelon.InfoLevel, logRenderer)
+	writer := logger.Scoped("build").Scoped("compile").AsWriter(echelon.InfoLevel)
+	chunk := []byte("compiling a file...\n")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = writer.Write(chunk)
+	}
+	logger.Finish(true)
+	renderer.rootNode.WaitCompletion()
+}
+
+func BenchmarkInteractiveRenderer_PerEvent(b *testing.B) {
+	renderer := newDevNullInteractiveRenderer(b)
+	benchmarkChattyScope(b, renderer, &unbatchedRenderer{renderer: renderer})
+}
+
+func BenchmarkInteractiveRenderer_Batched(b *testing.B) {
+	renderer := newDevNullInteractiveRenderer(b)
+	benchmarkChattyScope(b, renderer, renderer)
+}
+
+// screenRecorder keeps everything written to the terminal.
+type screenRecorder struct {
+	lock   sync.Mutex
+	output bytes.Buffer
+}
+
+func (s *screenRecorder) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.output.Write(p)
+}
+
+func (s *screenRecorder) String() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.output.String()
+}
+
+func Test_InteractiveRenderer_RedrawsOnlyOnChanges(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	renderer := NewInteractiveRenderer(out, nil)
+	go renderer.StartDrawing()
+
+	outputSize := func() int64 {
+		renderer.drawLock.Lock()
+		defer renderer.drawLock.Unlock()
+		_ = renderer.out.Flush()
+		stat, err := out.Stat()
+		require.NoError(t, err)
+		return stat.Size()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	idleSize := outputSize()
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, idleSize, outputSize(), "idle renderer should not draw anything")
+
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("foo"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "foo"))
+	time.Sleep(100 * time.Millisecond)
+	assert.Greater(t, outputSize(), idleSize, "changes should be drawn")
+	renderer.StopDrawing()
+}
+
+func Test_InteractiveRenderer_ConcurrentScopedLoggers(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	renderer := NewInteractiveRenderer(out, nil)
+	go renderer.StartDrawing()
+	logger := echelon.NewLogger(echelon.InfoLevel, renderer)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			scoped := logger.Scoped("stress").Scoped(fmt.Sprintf("Task %d", i))
+			for j := 0; j < 10; j++ {
+				step := scoped.Scoped(fmt.Sprintf("Step %d", j))
+				step.Infof("Working on %d", j)
+				_, _ = step.AsWriter(echelon.InfoLevel).Write([]byte("raw output\n"))
+				step.Finish(j%2 == 0)
+			}
+			scoped.Finish(true)
+		}(i)
+	}
+	wg.Wait()
+	logger.Finish(true)
+	renderer.rootNode.WaitCompletion()
+	renderer.StopDrawing()
+}
+
+func Test_InteractiveRenderer_RepaintsOnResize(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	renderer := NewInteractiveRenderer(out, nil)
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("foo"))
+	renderer.DrawFrame()
+
+	// pretend the terminal had a known size before being resized
+	renderer.terminalWidth, renderer.terminalHeight = 80, 24
+	renderer.resized = 1
+	renderer.DrawFrame()
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "\r\x1B[1A\x1B[J")
+	assert.Equal(t, -1, renderer.terminalWidth)
+	assert.Equal(t, -1, renderer.terminalHeight)
+}
+
+func Test_InteractiveRenderer_SynchronizedFramesWithHiddenCursor(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.SynchronizedOutput = config.FeatureEnabled
+	renderer := NewInteractiveRenderer(out, rendererConfig)
+	renderer.prepareTerminal()
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("foo"))
+	renderer.DrawFrame()
+	renderer.StopDrawing()
+
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	output := string(content)
+	assert.True(t, strings.HasPrefix(output, disableAutoWrap+hideCursor+beginSynchronizedUpdate), output)
+	assert.True(t, strings.HasSuffix(output, endSynchronizedUpdate+enableAutoWrap+showCursor), output)
+	assert.Equal(t, strings.Count(output, beginSynchronizedUpdate), strings.Count(output, endSynchronizedUpdate))
+}
+
+func Test_InteractiveRenderer_StopDrawingCompletesRoot(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	renderer := NewInteractiveRenderer(out, config.NewDefaultSymbolsOnlyRenderingConfig())
+	logger := echelon.NewLogger(echelon.InfoLevel, renderer)
+	logger.Scoped("build").Infof("compiling")
+	drawing := make(chan struct{})
+	go func() {
+		renderer.StartDrawing()
+		close(drawing)
+	}()
+	// the logger never finishes, the drawing stops anyway
+	renderer.StopDrawing()
+	<-drawing
+	renderer.rootNode.WaitCompletion()
+	assert.True(t, renderer.latestSnapshot().HasCompleted())
+}
+
+func Test_InteractiveRenderer_StopDrawingRemovesSpilledOutput(t *testing.T) {
+	t.Parallel()
+	for _, keep := range []bool{false, true} {
+		rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+		rendererConfig.MaxDescriptionLines = 2
+		rendererConfig.SpillDescriptionToDisk = true
+		rendererConfig.SpillDirectory = t.TempDir()
+		rendererConfig.KeepSpilledOutput = keep
+		renderer := NewInteractiveRendererForWriter(&screenRecorder{}, NewFixedSizeProvider(80, 10), rendererConfig)
+		renderer.RenderScopeStarted(echelon.NewLogScopeStarted("build"))
+		renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"build"}, echelon.InfoLevel, "one\ntwo\nthree\nfour"))
+		renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "build"))
+		files, err := filepath.Glob(filepath.Join(rendererConfig.SpillDirectory, "*"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		renderer.StopDrawing()
+		files, err = filepath.Glob(filepath.Join(rendererConfig.SpillDirectory, "*"))
+		require.NoError(t, err)
+		if !keep {
+			assert.Empty(t, files)
+			continue
+		}
+		assert.Len(t, files, 1)
+		var output bytes.Buffer
+		require.NoError(t, renderer.WriteScopeOutput(&output, "build"))
+		assert.Equal(t, "one\ntwo\nthree\nfour\n", output.String())
+		renderer.ReleaseOutput()
+		files, err = filepath.Glob(filepath.Join(rendererConfig.SpillDirectory, "*"))
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	}
+}
+
+func Test_InteractiveRenderer_Restore(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	renderer := NewInteractiveRenderer(out, config.NewDefaultSymbolsOnlyRenderingConfig())
+	renderer.prepareTerminal()
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("foo"))
+	renderer.DrawFrame()
+	renderer.Restore()
+	assert.True(t, renderer.isDone())
+
+	// nothing is drawn once the terminal is restored
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("bar"))
+	renderer.DrawFrame()
+	renderer.Println("Interrupted")
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	output := string(content)
+	assert.NotContains(t, output, "bar")
+	assert.True(t, strings.HasSuffix(output, enableAutoWrap+showCursor+"Interrupted\n"), output)
+}
+
+func Test_InteractiveRenderer_ReportsTitleTemplateErrorsOnce(t *testing.T) {
+	t.Parallel()
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	titleTemplate, err := ParseTemplate("title", "{{index .Path 1}}")
+	require.NoError(t, err)
+	rendererConfig.TitleTemplate = titleTemplate
+	var screen screenRecorder
+	renderer := NewInteractiveRendererForWriter(&screen, NewFixedSizeProvider(80, 10), rendererConfig)
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("first"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("second"))
+	renderer.DrawFrame()
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "first"))
+	renderer.DrawFrame()
+	assert.Equal(t, 1, strings.Count(screen.String(), "index out of range"), screen.String())
+	assert.Contains(t, renderer.currentFrameLines[0], "first")
+}
+
+func Test_InteractiveRenderer_CommitsFinishedTopLevelScopes(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.CommitFinishedScopes = true
+	renderer := NewInteractiveRenderer(out, rendererConfig)
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("first"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("second"))
+	renderer.DrawFrame()
+	assert.Len(t, renderer.currentFrameLines, 2)
+
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "first"))
+	renderer.DrawFrame()
+	renderer.DrawFrame()
+	require.Len(t, renderer.currentFrameLines, 1)
+	assert.Contains(t, renderer.currentFrameLines[0], "second")
+
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), "+ \x1B[32mfirst"), "finished scope is printed once")
+}
+
+func Test_InteractiveRenderer_CommitsGroupsThatGainMembers(t *testing.T) {
+	t.Parallel()
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.Colors = terminal.NoColorSchema()
+	rendererConfig.CommitFinishedScopes = true
+	rendererConfig.GroupKey = config.GroupMatrix()
+	var screen screenRecorder
+	renderer := NewInteractiveRendererForWriter(&screen, NewFixedSizeProvider(80, 10), rendererConfig)
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("test (a)"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("test (b)"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "test (a)"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "test (b)"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("lint (a)"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "lint (a)"))
+	renderer.DrawFrame()
+	assert.Empty(t, renderer.currentFrameLines)
+
+	// the committed members aren't drawn again, only the new ones are
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("test (c)"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("lint (b)"))
+	renderer.DrawFrame()
+	require.Len(t, renderer.currentFrameLines, 2)
+	assert.Contains(t, renderer.currentFrameLines[0], "test (c)")
+	assert.Contains(t, renderer.currentFrameLines[1], "lint (b)")
+
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("test (d)"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "test (c)"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "test (d)"))
+	renderer.DrawFrame()
+	require.Len(t, renderer.currentFrameLines, 1)
+	assert.Contains(t, renderer.currentFrameLines[0], "lint (b)")
+	// both groups of two are committed, the earlier members are never counted again
+	assert.Equal(t, 2, strings.Count(screen.String(), "test [2/2 passed]"), screen.String())
+	assert.NotContains(t, screen.String(), "/3")
+	assert.NotContains(t, screen.String(), "/4")
+	assert.Equal(t, 1, strings.Count(screen.String(), "lint (a)"), screen.String())
+}
+
+func Test_InteractiveRenderer_WithoutColors(t *testing.T) {
+	t.Parallel()
+	for _, theme := range []*terminal.Theme{nil, terminal.DarkTheme()} {
+		rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+		rendererConfig.Colors = nil
+		rendererConfig.Theme = theme
+		rendererConfig.GroupKey = config.GroupMatrix()
+		rendererConfig.ColumnLayout = true
+		var screen screenRecorder
+		renderer := NewInteractiveRendererForWriter(&screen, NewFixedSizeProvider(80, 10), rendererConfig)
+		for _, scope := range []string{"test (a)", "test (b)", "lint"} {
+			renderer.RenderScopeStarted(echelon.NewLogScopeStarted(scope))
+		}
+		renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"lint"}, echelon.WarnLevel, "unused variable"))
+		renderer.RenderMessage(echelon.NewLogEntryMessage([]string{"lint"}, echelon.ErrorLevel, "syntax error"))
+		renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "test (a)"))
+		renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "test (b)"))
+		renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSkipped, "lint"))
+		renderer.DrawFrame()
+		assert.Contains(t, screen.String(), "1/2 passed, 1 failed")
+		assert.Contains(t, screen.String(), "1 warning")
+
+		fullScreen := NewFullScreenRendererForWriter(nil, &screen, NewFixedSizeProvider(80, 10), rendererConfig)
+		fullScreen.RenderScopeStarted(echelon.NewLogScopeStarted("build"))
+		fullScreen.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "build"))
+		assert.Contains(t, strings.Join(fullScreen.renderScreen(), "\n"), "build")
+	}
+}
+
+func Test_InteractiveRenderer_DeprecatedRefreshRate(t *testing.T) {
+	t.Parallel()
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	renderer := NewInteractiveRendererForWriter(&screenRecorder{}, NewFixedSizeProvider(80, 10), rendererConfig)
+	assert.Equal(t, time.Second/time.Duration(rendererConfig.MaxFrameRate), renderer.frameInterval())
+
+	rendererConfig = config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.RefreshRate = 200 * time.Millisecond
+	renderer = NewInteractiveRendererForWriter(&screenRecorder{}, NewFixedSizeProvider(80, 10), rendererConfig)
+	assert.Equal(t, 200*time.Millisecond, renderer.frameInterval())
+}
+
+func Test_InteractiveRenderer_PrintlnAboveFrame(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	renderer := NewInteractiveRenderer(out, config.NewDefaultSymbolsOnlyRenderingConfig())
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("foo"))
+	renderer.DrawFrame()
+	frame := renderer.currentFrameLines[0]
+
+	renderer.Println("Hello,", "World!")
+	_, _ = renderer.Write([]byte("Incomplete"))
+	renderer.StopDrawing()
+
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "\r\x1B[1A\x1B[JHello, World!\n"+frame+"\n")
+	assert.Contains(t, string(content), "\x1B[JIncomplete\n")
+}
+
+func Test_InteractiveRenderer_SuspendAndResume(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	renderer := NewInteractiveRenderer(out, config.NewDefaultSymbolsOnlyRenderingConfig())
+	renderer.prepareTerminal()
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("foo"))
+	renderer.DrawFrame()
+	before, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+
+	renderer.Suspend()
+	suspended, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "\r\x1B[1A\x1B[J"+enableAutoWrap+showCursor, string(suspended[len(before):]))
+
+	// nothing is drawn while suspended
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("bar"))
+	renderer.Println("Hello")
+	renderer.DrawFrame()
+	stillSuspended, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, suspended, stillSuspended)
+
+	renderer.Resume()
+	renderer.StopDrawing()
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	resumed := string(content[len(suspended):])
+	assert.True(t, strings.HasPrefix(resumed, disableAutoWrap+hideCursor+"\r\x1B[JHello\n"), resumed)
+	assert.Len(t, renderer.currentFrameLines, 2)
+	for _, line := range renderer.currentFrameLines {
+		assert.Contains(t, resumed, line+"\n")
+	}
+}
+
+func Test_InteractiveRenderer_NestedSuspend(t *testing.T) {
+	t.Parallel()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	renderer := NewInteractiveRenderer(out, config.NewDefaultSymbolsOnlyRenderingConfig())
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("foo"))
+	renderer.Suspend()
+	renderer.Suspend()
+	renderer.Resume()
+	renderer.DrawFrame()
+	assert.Empty(t, renderer.currentFrameLines)
+	renderer.Resume()
+	assert.Len(t, renderer.currentFrameLines, 1)
+	// unbalanced calls are ignored
+	renderer.Resume()
+	renderer.DrawFrame()
+	assert.Len(t, renderer.currentFrameLines, 1)
+}
+
+func Test_InteractiveRenderer_BadgesAndCountsInColumns(t *testing.T) {
+	t.Parallel()
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.ColumnLayout = true
+	var screen screenRecorder
+	renderer := NewInteractiveRendererForWriter(&screen, NewFixedSizeProvider(60, 10), rendererConfig)
+	warning := echelon.NewLogEntryMessage([]string{"foo"}, echelon.WarnLevel, "careful")
+	renderer.RenderBatch([]*echelon.Event{
+		{LogStarted: echelon.NewLogScopeStarted("foo")},
+		{LogBadge: echelon.NewLogScopeBadge("version", "v1.2", "foo")},
+		{LogEntry: warning},
+		{LogEntry: warning},
+		{LogEntry: echelon.NewLogEntryMessage([]string{"foo"}, echelon.ErrorLevel, "oops")},
+	})
+	renderer.DrawFrame()
+	title := renderer.currentFrameLines[0]
+	assert.Equal(t, 60, terminal.StringWidth(title), title)
+	assert.Contains(t, title, "[v1.2] ")
+	assert.Contains(t, title, "2 warnings")
+	assert.Contains(t, title, "1 error")
+}
+
+func Test_InteractiveRenderer_FitsFrameToScreen(t *testing.T) {
+	t.Parallel()
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.FitToScreen = true
+	var screen screenRecorder
+	renderer := NewInteractiveRendererForWriter(&screen, NewFixedSizeProvider(80, 5), rendererConfig)
+	for i := 0; i < 20; i++ {
+		scope := fmt.Sprintf("scope %d", i)
+		renderer.RenderScopeStarted(echelon.NewLogScopeStarted(scope))
+		if i%2 == 0 {
+			renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, scope))
+		}
+	}
+	renderer.DrawFrame()
+	assert.Len(t, renderer.currentFrameLines, 5)
+	assert.Contains(t, renderer.currentFrameLines[3], "10 more succeeded")
+	assert.Equal(t, "+7 more running", renderer.currentFrameLines[4])
+}
+
+func Test_InteractiveRenderer_ScopesWithSlashes(t *testing.T) {
+	t.Parallel()
+	renderer := NewInteractiveRendererForWriter(&screenRecorder{}, NewFixedSizeProvider(80, 10), nil)
+	renderer.RenderBatch([]*echelon.Event{
+		{LogStarted: echelon.NewLogScopeStarted("a/b")},
+		{LogStarted: echelon.NewLogScopeStarted("a", "b")},
+		{LogEntry: echelon.NewLogEntryMessage([]string{"a/b"}, echelon.InfoLevel, "slash")},
+		{LogEntry: echelon.NewLogEntryMessage([]string{"a", "b"}, echelon.InfoLevel, "nested")},
+		{LogRetention: echelon.NewLogScopeRetention(echelon.FinishTypeSucceeded,
+			echelon.RetentionPolicy{Mode: echelon.RemoveScope}, "a/b")},
+		{LogFinished: echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "a", "b")},
+	})
+	// the policy of "a/b" doesn't apply to "b" in "a" and the output goes to the right scopes
+	snapshot := renderer.latestSnapshot()
+	require.NotNil(t, snapshot.FindChild("a", "b"))
+	require.NotNil(t, snapshot.FindChild("a/b"))
+	assert.Equal(t, "slash", snapshot.FindChild("a/b").VisibleDescription()[0])
+	assert.NotContains(t, snapshot.FindChild("a/b").VisibleDescription(), "nested")
+}
+
+func Test_InteractiveRenderer_RetentionPolicies(t *testing.T) {
+	t.Parallel()
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.Colors = terminal.NoColorSchema()
+	rendererConfig.RetentionWhenSkipped = echelon.RetentionPolicy{Mode: echelon.RemoveScope}
+	var screen screenRecorder
+	renderer := NewInteractiveRendererForWriter(&screen, NewFixedSizeProvider(80, 10), rendererConfig)
+	events := []*echelon.Event{
+		{LogStarted: echelon.NewLogScopeStarted("deploy")},
+		{LogRetention: echelon.NewLogScopeRetention(echelon.FinishTypeSucceeded,
+			echelon.RetentionPolicy{Mode: echelon.RetainDescription, Lines: 3}, "deploy")},
+		{LogStarted: echelon.NewLogScopeStarted("deploy", "upload")},
+	}
+	for i := 1; i <= 4; i++ {
+		events = append(events, &echelon.Event{
+			LogEntry: echelon.NewLogEntryMessage([]string{"deploy"}, echelon.InfoLevel, "line %d", i),
+		})
+	}
+	events = append(events,
+		&echelon.Event{LogFinished: echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "deploy", "upload")},
+		&echelon.Event{LogFinished: echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "deploy")},
+		&echelon.Event{LogStarted: echelon.NewLogScopeStarted("lint")},
+		&echelon.Event{LogEntry: echelon.NewLogEntryMessage([]string{"lint"}, echelon.InfoLevel, "no issues")},
+		&echelon.Event{LogFinished: echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "lint")},
+		&echelon.Event{LogStarted: echelon.NewLogScopeStarted("docs")},
+		&echelon.Event{LogFinished: echelon.NewLogScopeFinished(echelon.FinishTypeSkipped, "docs")},
+	)
+	renderer.RenderBatch(events)
+	renderer.DrawFrame()
+	lines := renderer.currentFrameLines
+	require.Len(t, lines, 6)
+	assert.True(t, strings.HasPrefix(lines[0], "+ deploy "), lines[0])
+	assert.Equal(t, []string{"  ...", "  line 2", "  line 3", "  line 4"}, lines[1:5])
+	assert.True(t, strings.HasPrefix(lines[5], "+ lint "), lines[5])
+}
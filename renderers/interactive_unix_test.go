@@ -0,0 +1,125 @@
+//go:build !windows
+// +build !windows
+
+//nolint:testpackage
+package renderers
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:paralleltest // redirects standard streams of the whole process
+func Test_InteractiveRenderer_CaptureStandardStreams(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	rendererConfig := config.NewDefaultRenderingConfig()
+	rendererConfig.SynchronizedOutput = config.FeatureDisabled
+	renderer := NewInteractiveRenderer(out, rendererConfig)
+	renderer.currentFrameLines = []string{"Frame"}
+
+	restore, err := renderer.CaptureStandardStreams()
+	require.NoError(t, err)
+	fmt.Println("Stray stdout")
+	fmt.Fprint(os.Stderr, "Stray stderr")
+	require.NoError(t, restore())
+
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "\r\x1B[1A\x1B[JStray stdout\nFrame\n\r\x1B[1A\x1B[JStray stderr\nFrame\n", string(content))
+}
+
+//nolint:paralleltest // the signal is delivered to the whole process
+func Test_handleTermination_RestoreOnly(t *testing.T) {
+	applicationSignals := make(chan os.Signal, 2)
+	signal.Notify(applicationSignals, syscall.SIGHUP)
+	defer signal.Stop(applicationSignals)
+	restored := make(chan struct{}, 2)
+	stopHandling := handleTermination(config.SignalsRestore, func() {
+		restored <- struct{}{}
+	})
+	defer stopHandling()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	select {
+	case <-restored:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the terminal is not restored")
+	}
+	<-applicationSignals
+	// the application gets the signal once since it's not raised again
+	select {
+	case <-applicationSignals:
+		assert.Fail(t, "the signal is delivered twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+//nolint:paralleltest // the signal is delivered to the whole process
+func Test_handleTermination_Ignore(t *testing.T) {
+	applicationSignals := make(chan os.Signal, 1)
+	signal.Notify(applicationSignals, syscall.SIGHUP)
+	defer signal.Stop(applicationSignals)
+	restored := make(chan struct{}, 1)
+	stopHandling := handleTermination(config.SignalsIgnore, func() {
+		restored <- struct{}{}
+	})
+	defer stopHandling()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	<-applicationSignals
+	select {
+	case <-restored:
+		assert.Fail(t, "the signal is handled by the renderer")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+//nolint:paralleltest // the signal is delivered to the whole process
+func Test_InteractiveRenderer_RestoresOnSignalWithVisibleCursor(t *testing.T) {
+	applicationSignals := make(chan os.Signal, 1)
+	signal.Notify(applicationSignals, syscall.SIGHUP)
+	defer signal.Stop(applicationSignals)
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.HideCursor = false
+	rendererConfig.TerminationSignals = config.SignalsRestore
+	renderer := NewInteractiveRenderer(out, rendererConfig)
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("foo"))
+	drawing := make(chan struct{})
+	go func() {
+		renderer.StartDrawing()
+		close(drawing)
+	}()
+	// the first frame is drawn once the signals are handled
+	require.Eventually(t, func() bool {
+		content, err := os.ReadFile(out.Name())
+		return err == nil && strings.Contains(string(content), "foo")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	<-applicationSignals
+	select {
+	case <-drawing:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the drawing is not stopped")
+	}
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(content), enableAutoWrap), string(content))
+	assert.NotContains(t, string(content), hideCursor)
+}
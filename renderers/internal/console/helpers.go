@@ -6,6 +6,8 @@ package console
 import (
 	"golang.org/x/sys/unix"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func PrepareTerminalEnvironment() error {
@@ -14,10 +16,70 @@ func PrepareTerminalEnvironment() error {
 }
 
 func TerminalHeight(file *os.File) int {
+	_, height := TerminalSize(file)
+	return height
+}
+
+// IsTerminal returns true if the file is a terminal.
+func IsTerminal(file *os.File) bool {
+	_, err := unix.IoctlGetWinsize(int(file.Fd()), unix.TIOCGWINSZ)
+	return err == nil
+}
+
+// TerminalSize returns width and height of the terminal or -1 if they're unknown.
+func TerminalSize(file *os.File) (int, int) {
 	ws, err := unix.IoctlGetWinsize(int(file.Fd()), unix.TIOCGWINSZ)
 	if err != nil {
-		return -1
+		return -1, -1
 	}
 
-	return int(ws.Row)
+	return int(ws.Col), int(ws.Row)
+}
+
+// NotifyResize relays terminal size changes to the channel.
+func NotifyResize(c chan<- os.Signal) {
+	signal.Notify(c, unix.SIGWINCH)
+}
+
+// StopNotifyResize stops relaying terminal size changes to the channel.
+func StopNotifyResize(c chan<- os.Signal) {
+	signal.Stop(c)
+}
+
+// NotifyTermination relays signals that terminate the process by default to the channel.
+func NotifyTermination(c chan<- os.Signal) {
+	signal.Notify(c, unix.SIGINT, unix.SIGTERM, unix.SIGHUP)
+}
+
+// StopNotifyTermination stops relaying termination signals to the channel.
+func StopNotifyTermination(c chan<- os.Signal) {
+	signal.Stop(c)
+}
+
+// Reraise sends the signal to the current process again so its default action or other handlers apply.
+func Reraise(sig os.Signal) {
+	if sysSignal, ok := sig.(syscall.Signal); ok {
+		_ = unix.Kill(os.Getpid(), sysSignal)
+	}
+}
+
+// RedirectOutput points the file descriptor to the given file and returns a duplicate of the original one.
+func RedirectOutput(fd int, to *os.File) (*os.File, error) {
+	originalFd, err := unix.Dup(fd)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Dup2(int(to.Fd()), fd); err != nil {
+		_ = unix.Close(originalFd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(originalFd), "original"), nil
+}
+
+// RestoreOutput points the file descriptor back to the original file returned by RedirectOutput and closes it.
+func RestoreOutput(fd int, original *os.File) error {
+	if err := unix.Dup2(int(original.Fd()), fd); err != nil {
+		return err
+	}
+	return original.Close()
 }
@@ -1,8 +1,11 @@
 package console
 
 import (
+	"errors"
 	"golang.org/x/sys/windows"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func PrepareTerminalEnvironment() error {
@@ -28,3 +31,54 @@ func TerminalHeight(file *os.File) int {
 	// todo: figure out how to find out console height on Windows
 	return -1
 }
+
+// IsTerminal returns true if the file is a console.
+func IsTerminal(file *os.File) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(file.Fd()), &mode) == nil
+}
+
+// TerminalSize returns width and height of the terminal or -1 if they're unknown.
+func TerminalSize(file *os.File) (int, int) {
+	// todo: figure out how to find out console size on Windows
+	return -1, -1
+}
+
+// NotifyResize relays terminal size changes to the channel.
+func NotifyResize(c chan<- os.Signal) {
+	// there is no SIGWINCH on Windows
+}
+
+// StopNotifyResize stops relaying terminal size changes to the channel.
+func StopNotifyResize(c chan<- os.Signal) {
+	// there is no SIGWINCH on Windows
+}
+
+// NotifyTermination relays signals that terminate the process by default to the channel.
+func NotifyTermination(c chan<- os.Signal) {
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+}
+
+// StopNotifyTermination stops relaying termination signals to the channel.
+func StopNotifyTermination(c chan<- os.Signal) {
+	signal.Stop(c)
+}
+
+// Reraise terminates the process since signals can't be sent to itself on Windows.
+func Reraise(sig os.Signal) {
+	//nolint:gomnd
+	os.Exit(2)
+}
+
+var ErrRedirectNotSupported = errors.New("redirecting standard streams is not supported on Windows")
+
+// RedirectOutput points the file descriptor to the given file and returns a duplicate of the original one.
+func RedirectOutput(fd int, to *os.File) (*os.File, error) {
+	// todo: figure out how to redirect standard streams of the process and its children on Windows
+	return nil, ErrRedirectNotSupported
+}
+
+// RestoreOutput points the file descriptor back to the original file returned by RedirectOutput and closes it.
+func RestoreOutput(fd int, original *os.File) error {
+	return ErrRedirectNotSupported
+}
@@ -0,0 +1,97 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package console
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// EnableKeyboardInput switches the terminal to non-canonical mode without echo so single key presses
+// can be read as they come. Unlike a fully raw mode it keeps signal generation and output processing,
+// so Ctrl+C still interrupts the process. The returned function restores the previous mode.
+func EnableKeyboardInput(file *os.File) (func() error, error) {
+	fd := int(file.Fd())
+	original, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+	keyboard := *original
+	keyboard.Iflag &^= unix.IXON | unix.ICRNL | unix.INLCR | unix.IGNCR
+	keyboard.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.IEXTEN
+	keyboard.Cc[unix.VMIN] = 1
+	keyboard.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, &keyboard); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return unix.IoctlSetTermios(fd, ioctlWriteTermios, original)
+	}, nil
+}
+
+// KeyReader reads from a terminal until it's cancelled, so that nothing typed after that is consumed.
+// Deadlines don't work for terminals, hence the input is waited for together with a pipe written on cancellation.
+type KeyReader struct {
+	fd          int
+	cancelRead  *os.File
+	cancelWrite *os.File
+}
+
+func NewKeyReader(file *os.File) (*KeyReader, error) {
+	cancelRead, cancelWrite, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	return &KeyReader{fd: int(file.Fd()), cancelRead: cancelRead, cancelWrite: cancelWrite}, nil
+}
+
+// Read waits for input and reads it or returns io.EOF once Cancel is called.
+func (r *KeyReader) Read(p []byte) (int, error) {
+	cancelFd := int(r.cancelRead.Fd())
+	maxFd := r.fd
+	if cancelFd > maxFd {
+		maxFd = cancelFd
+	}
+	for {
+		var fds unix.FdSet
+		fds.Set(r.fd)
+		fds.Set(cancelFd)
+		if _, err := unix.Select(maxFd+1, &fds, nil, nil, nil); err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return 0, err
+		}
+		if fds.IsSet(cancelFd) {
+			return 0, io.EOF
+		}
+		if !fds.IsSet(r.fd) {
+			continue
+		}
+		n, err := unix.Read(r.fd, p)
+		if err == unix.EINTR || err == unix.EAGAIN {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			return 0, io.EOF
+		}
+		return n, nil
+	}
+}
+
+// Cancel makes the pending and all the following reads return io.EOF without reading anything.
+func (r *KeyReader) Cancel() {
+	_, _ = r.cancelWrite.Write([]byte{0})
+}
+
+// Close releases the pipe once nothing reads anymore.
+func (r *KeyReader) Close() error {
+	_ = r.cancelWrite.Close()
+	return r.cancelRead.Close()
+}
@@ -0,0 +1,34 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package console
+
+import (
+	"errors"
+	"os"
+)
+
+var ErrKeyboardInputNotSupported = errors.New("reading single key presses is not supported on this platform")
+
+// EnableKeyboardInput is not supported on this platform.
+func EnableKeyboardInput(file *os.File) (func() error, error) {
+	return nil, ErrKeyboardInputNotSupported
+}
+
+// KeyReader is not supported on this platform.
+type KeyReader struct{}
+
+// NewKeyReader is not supported on this platform.
+func NewKeyReader(file *os.File) (*KeyReader, error) {
+	return nil, ErrKeyboardInputNotSupported
+}
+
+func (r *KeyReader) Read(p []byte) (int, error) {
+	return 0, ErrKeyboardInputNotSupported
+}
+
+func (r *KeyReader) Cancel() {}
+
+func (r *KeyReader) Close() error {
+	return nil
+}
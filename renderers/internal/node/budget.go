@@ -0,0 +1,239 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/cirruslabs/echelon/renderers/config"
+)
+
+// layoutOptions make a tree take less lines when it doesn't fit on the screen.
+type layoutOptions struct {
+	// maxTail limits the lines of output shown below each title, negative means as configured
+	maxTail int
+	// collapseFinished replaces succeeded and skipped siblings with a summary line
+	collapseFinished bool
+	// maxRunning limits the running siblings shown, the rest are summarized, negative means unlimited
+	maxRunning int
+}
+
+var fullLayout = layoutOptions{maxTail: -1, maxRunning: -1}
+
+// RenderWithin renders the snapshots as siblings in at most maxLines lines if possible. Under pressure the output
+// below titles shrinks down to a single line, then succeeded and skipped siblings collapse into summary lines,
+// and then only the first running siblings are shown. Failed scopes are never hidden, so the result can still be
+// taller than maxLines. Non-positive maxLines means there is no limit. Siblings are drawn in the configured order.
+func RenderWithin(snapshots []*Snapshot, width int, columns ColumnWidths, maxLines int) []string {
+	options := fitLayout(snapshots, width, maxLines)
+	if options == fullLayout && !usesGrid(snapshots) {
+		var result []string
+		for _, snapshot := range sortSiblings(snapshots) {
+			result = append(result, snapshot.RenderAligned(width, columns)...)
+		}
+		return result
+	}
+	return renderGroup(snapshots, width, columns, "", nil, options)
+}
+
+// fitLayout returns the least compact options to fit the snapshots in maxLines.
+func fitLayout(snapshots []*Snapshot, width int, maxLines int) layoutOptions {
+	if maxLines <= 0 || len(snapshots) == 0 || groupLineCount(snapshots, width, fullLayout) <= maxLines {
+		return fullLayout
+	}
+	visibleLines := snapshots[0].config.VisibleDescriptionLines
+	for maxTail := visibleLines; maxTail >= 1; maxTail-- {
+		options := layoutOptions{maxTail: maxTail, maxRunning: -1}
+		if groupLineCount(snapshots, width, options) <= maxLines {
+			return options
+		}
+	}
+	collapsed := layoutOptions{maxTail: 1, collapseFinished: true, maxRunning: -1}
+	if groupLineCount(snapshots, width, collapsed) <= maxLines {
+		return collapsed
+	}
+	// the amount of lines only grows with the amount of running siblings shown
+	low, high := 1, maxGroupSize(snapshots)
+	for low < high {
+		middle := (low + high + 1) / 2
+		collapsed.maxRunning = middle
+		if groupLineCount(snapshots, width, collapsed) <= maxLines {
+			low = middle
+		} else {
+			high = middle - 1
+		}
+	}
+	collapsed.maxRunning = low
+	return collapsed
+}
+
+func maxGroupSize(snapshots []*Snapshot) int {
+	result := len(snapshots)
+	for _, snapshot := range snapshots {
+		if size := maxGroupSize(snapshot.children); size > result {
+			result = size
+		}
+	}
+	return result
+}
+
+// groupSummary is what's left of a group of siblings after hiding some of them.
+type groupSummary struct {
+	visible       []*Snapshot
+	succeeded     int
+	skipped       int
+	hiddenRunning int
+}
+
+func summarizeGroup(snapshots []*Snapshot, options layoutOptions) groupSummary {
+	if !options.collapseFinished && options.maxRunning < 0 {
+		return groupSummary{visible: snapshots}
+	}
+	var result groupSummary
+	running := 0
+	for _, snapshot := range snapshots {
+		switch {
+		case options.collapseFinished && snapshot.hasSucceeded():
+			result.succeeded++
+		case options.collapseFinished && snapshot.wasSkipped():
+			result.skipped++
+		case options.maxRunning >= 0 && snapshot.IsRunning() && running >= options.maxRunning:
+			result.hiddenRunning++
+		default:
+			if snapshot.IsRunning() {
+				running++
+			}
+			result.visible = append(result.visible, snapshot)
+		}
+	}
+	return result
+}
+
+func (summary groupSummary) lines(cfg *config.InteractiveRendererConfig) []string {
+	var result []string
+	if summary.succeeded > 0 {
+		result = append(result, fmt.Sprintf("%s %s", cfg.SuccessStatus,
+			cfg.PaintTitle(config.StateSucceeded, fmt.Sprintf("%d more succeeded", summary.succeeded))))
+	}
+	if summary.skipped > 0 {
+		result = append(result, fmt.Sprintf("%s %s", cfg.SkippedStatus,
+			cfg.PaintTitle(config.StateSkipped, fmt.Sprintf("%d more skipped", summary.skipped))))
+	}
+	if summary.hiddenRunning > 0 {
+		result = append(result, fmt.Sprintf("+%d more running", summary.hiddenRunning))
+	}
+	return result
+}
+
+func (snapshot *Snapshot) hasSucceeded() bool {
+	return snapshot.HasCompleted() && snapshot.status == snapshot.config.SuccessStatus
+}
+
+func (snapshot *Snapshot) wasSkipped() bool {
+	return snapshot.HasCompleted() && snapshot.status == snapshot.config.SkippedStatus
+}
+
+func groupLineCount(snapshots []*Snapshot, width int, options layoutOptions) int {
+	if usesGrid(snapshots) {
+		// the grid is already compact, only the failed snapshots below it are affected by the options
+		result := len(gridLines(snapshots, width))
+		for _, snapshot := range failedSnapshots(snapshots) {
+			result += snapshot.lineCount(width, options)
+		}
+		return result
+	}
+	summary := summarizeGroup(snapshots, options)
+	result := 0
+	for _, snapshot := range summary.visible {
+		result += snapshot.lineCount(width, options)
+	}
+	if summary.succeeded > 0 {
+		result++
+	}
+	if summary.skipped > 0 {
+		result++
+	}
+	if summary.hiddenRunning > 0 {
+		result++
+	}
+	return result
+}
+
+func (snapshot *Snapshot) lineCount(width int, options layoutOptions) int {
+	description, ellipsis := snapshot.limitedDescription(options)
+	childrenWidth := tailWidth(width, snapshot.indent(snapshot.statusPrefix()))
+	result := 1 + groupLineCount(snapshot.children, childrenWidth, options) + len(description)
+	if ellipsis {
+		result++
+	}
+	return result
+}
+
+// limitedDescription returns the lines of output to show and whether some of the lines are omitted.
+func (snapshot *Snapshot) limitedDescription(options layoutOptions) ([]string, bool) {
+	if options.maxTail < 0 {
+		return snapshot.description, snapshot.descriptionOmitted
+	}
+	description := snapshot.description
+	// an empty line after the last line break is a waste of space
+	for len(description) > 0 && description[len(description)-1] == "" {
+		description = description[:len(description)-1]
+	}
+	if len(description) > options.maxTail {
+		description = description[len(description)-options.maxTail:]
+	}
+	return description, false
+}
+
+// renderGroup renders the siblings that are left after applying the options followed by the summary lines.
+func renderGroup(
+	snapshots []*Snapshot,
+	width int,
+	columns ColumnWidths,
+	indent string,
+	guides *config.TreeGuides,
+	options layoutOptions,
+) []string {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	if usesGrid(snapshots) {
+		var result []string
+		for _, line := range gridLines(snapshots, width) {
+			result = append(result, indent+line)
+		}
+		failed := failedSnapshots(snapshots)
+		for i, snapshot := range failed {
+			result = appendNested(result, snapshot.renderCompact(width, columns, options), i == len(failed)-1, indent, guides)
+		}
+		return result
+	}
+	summary := summarizeGroup(sortSiblings(snapshots), options)
+	summaryLines := summary.lines(snapshots[0].config)
+	var result []string
+	for i, snapshot := range summary.visible {
+		last := i == len(summary.visible)-1 && len(summaryLines) == 0
+		result = appendNested(result, snapshot.renderCompact(width, columns, options), last, indent, guides)
+	}
+	for i, line := range summaryLines {
+		result = appendNested(result, []string{truncate(line, width)}, i == len(summaryLines)-1, indent, guides)
+	}
+	return result
+}
+
+// renderCompact is like render with the options applied. It doesn't use the cache since it's used only
+// when the tree doesn't fit on the screen.
+func (snapshot *Snapshot) renderCompact(width int, columns ColumnWidths, options layoutOptions) []string {
+	prefix := snapshot.statusPrefix()
+	indent := snapshot.indent(prefix)
+	tailWidth := tailWidth(width, indent)
+	result := []string{snapshot.renderTitle(prefix, width, columns)}
+	result = append(result, renderGroup(snapshot.children, tailWidth, snapshot.ChildColumnWidths(), indent,
+		snapshot.config.Guides(), options)...)
+	description, ellipsis := snapshot.limitedDescription(options)
+	if ellipsis {
+		result = append(result, indent+"...")
+	}
+	for _, line := range description {
+		result = append(result, indent+truncate(line, tailWidth))
+	}
+	return result
+}
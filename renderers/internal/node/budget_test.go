@@ -0,0 +1,77 @@
+//nolint:testpackage
+package node
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RenderWithin_FitsWithoutChanges(t *testing.T) {
+	t.Parallel()
+	root := newTestTree(2, 3, true)
+	snapshot := root.Snapshot()
+	lines := RenderWithin(snapshot.GetChildren(), 0, ColumnWidths{}, 100)
+	var expected []string
+	for _, child := range snapshot.GetChildren() {
+		expected = append(expected, child.Render(0)...)
+	}
+	assert.Equal(t, expected, lines)
+}
+
+func Test_RenderWithin_ShrinksOutput(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	root := NewEchelonNode("root", testConfig)
+	running := root.StartNewChild("running")
+	running.AppendDescription("1\n2\n3\n4\n5\n")
+	lines := RenderWithin(root.Snapshot().GetChildren(), 0, ColumnWidths{}, 4)
+	assert.Len(t, lines, 4)
+	assert.Equal(t, []string{"  3", "  4", "  5"}, lines[1:])
+}
+
+func Test_RenderWithin_CollapsesFinishedSiblings(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	testConfig.Colors = terminal.NoColorSchema()
+	root := NewEchelonNode("root", testConfig)
+	parent := root.StartNewChild("parent")
+	for i := 0; i < 37; i++ {
+		parent.StartNewChild(fmt.Sprintf("succeeded %d", i)).CompleteWithColor(testConfig.SuccessStatus, -1)
+	}
+	failed := parent.StartNewChild("failed")
+	failed.AppendDescription("error")
+	failed.CompleteWithColor(testConfig.FailureStatus, -1)
+	parent.StartNewChild("skipped").CompleteWithColor(testConfig.SkippedStatus, -1)
+	parent.StartNewChild("running")
+
+	lines := RenderWithin(root.Snapshot().GetChildren(), 0, ColumnWidths{}, 10)
+	assert.Len(t, lines, 6)
+	assert.True(t, strings.HasPrefix(lines[1], "  - failed"), lines[1])
+	assert.Equal(t, "    error", lines[2])
+	assert.Contains(t, lines[3], " running")
+	assert.Equal(t, "  + 37 more succeeded", lines[4])
+	assert.Equal(t, "  ! 1 more skipped", lines[5])
+}
+
+func Test_RenderWithin_LimitsRunningSiblings(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	root := NewEchelonNode("root", testConfig)
+	parent := root.StartNewChild("parent")
+	for i := 0; i < 20; i++ {
+		parent.StartNewChild(fmt.Sprintf("running %d", i))
+	}
+	failed := parent.StartNewChild("failed")
+	failed.CompleteWithColor(testConfig.FailureStatus, -1)
+
+	lines := RenderWithin(root.Snapshot().GetChildren(), 0, ColumnWidths{}, 6)
+	assert.Len(t, lines, 6)
+	assert.Contains(t, lines[1], "running 0")
+	assert.Contains(t, lines[3], "running 2")
+	assert.Contains(t, lines[4], "failed")
+	assert.Equal(t, "  +17 more running", lines[5])
+}
@@ -0,0 +1,125 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/cirruslabs/echelon/utils"
+)
+
+// columns right-aligned in the column layout from left to right
+const (
+	columnBadges = iota
+	columnWarnings
+	columnErrors
+	columnProgress
+	columnDuration
+	columnsCount
+)
+
+// ColumnWidths are the widths of the right-aligned columns shared by sibling scopes so the columns line up.
+type ColumnWidths [columnsCount]int
+
+// ChildColumnWidths returns the widths of the columns wide enough for all children of the snapshot.
+func (snapshot *Snapshot) ChildColumnWidths() ColumnWidths {
+	var result ColumnWidths
+	if !snapshot.config.ColumnLayout {
+		return result
+	}
+	for _, child := range snapshot.children {
+		for i, value := range child.columns() {
+			if width := terminal.StringWidth(value); width > result[i] {
+				result[i] = width
+			}
+		}
+	}
+	return result
+}
+
+// columns returns uncolored values of the columns, empty values are not shown.
+func (snapshot *Snapshot) columns() [columnsCount]string {
+	var result [columnsCount]string
+	badges := make([]string, 0, len(snapshot.badges))
+	for _, b := range snapshot.badges {
+		badges = append(badges, "["+b.text+"]")
+	}
+	result[columnBadges] = strings.Join(badges, " ")
+	if snapshot.warnings > 0 {
+		result[columnWarnings] = pluralize(snapshot.warnings, "warning")
+	}
+	if snapshot.errors > 0 {
+		result[columnErrors] = pluralize(snapshot.errors, "error")
+	}
+	if len(snapshot.children) > 0 {
+		completed := 0
+		for _, child := range snapshot.children {
+			if child.HasCompleted() {
+				completed++
+			}
+		}
+		result[columnProgress] = fmt.Sprintf("%d/%d", completed, len(snapshot.children))
+	}
+	result[columnDuration] = utils.FormatDuration(snapshot.ExecutionDuration(), len(snapshot.children) == 0)
+	return result
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// columnTitle puts the title on the left and the columns on the right of the line of the given width.
+// Columns are dropped if there is no space left for the title.
+func (snapshot *Snapshot) columnTitle(prefix string, width int, widths ColumnWidths) string {
+	values := snapshot.columns()
+	parts := make([]string, 0, columnsCount)
+	rightWidth := 0
+	for i, value := range values {
+		valueWidth := terminal.StringWidth(value)
+		columnWidth := widths[i]
+		if valueWidth > columnWidth {
+			columnWidth = valueWidth
+		}
+		if columnWidth == 0 {
+			continue
+		}
+		if len(parts) > 0 {
+			rightWidth++
+		}
+		rightWidth += columnWidth
+		parts = append(parts, strings.Repeat(" ", columnWidth-valueWidth)+snapshot.colorColumn(i, value))
+	}
+	left := snapshot.paintPrefix(prefix) + " " + snapshot.coloredTitle()
+	// keep at least a single character of the title and a space before the columns
+	available := width - rightWidth - 1
+	if available < terminal.StringWidth(prefix)+2 {
+		return truncate(snapshot.fancyTitle(prefix), width)
+	}
+	left = terminal.Truncate(left, available, terminal.Ellipsis)
+	return left + strings.Repeat(" ", width-terminal.StringWidth(left)-rightWidth) + strings.Join(parts, " ")
+}
+
+func (snapshot *Snapshot) colorColumn(column int, value string) string {
+	if value == "" {
+		return value
+	}
+	switch column {
+	case columnWarnings:
+		if snapshot.config.Theme != nil {
+			return snapshot.config.PaintMessage(echelon.WarnLevel, value)
+		}
+		return terminal.GetColoredText(snapshot.config.ColorSchema().NeutralColor, value)
+	case columnErrors:
+		if snapshot.config.Theme != nil {
+			return snapshot.config.PaintMessage(echelon.ErrorLevel, value)
+		}
+		return terminal.GetColoredText(snapshot.config.ColorSchema().FailureColor, value)
+	case columnDuration:
+		return snapshot.config.PaintDuration(value)
+	}
+	return value
+}
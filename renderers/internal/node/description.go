@@ -0,0 +1,293 @@
+package node
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrOutputNotAvailable = errors.New("output is no longer available")
+
+// descriptionBuffer keeps the last maxLines lines of a node's output in a ring buffer. Older lines are either
+// spilled to a temporary file or dropped. Since the full output can be requested from any goroutine,
+// the buffer has its own lock.
+type descriptionBuffer struct {
+	lock     sync.Mutex
+	maxLines int // non-positive means unlimited
+	lines    []string
+	start    int   // index of the oldest line in lines
+	evicted  int   // lines that didn't fit in memory
+	verbose  []int // ascending indexes of lines started by verbose text, e.g. debug messages
+
+	spillEnabled bool
+	spillDir     string
+	spillFile    *os.File
+	spillWriter  *bufio.Writer
+	spilledBytes int64
+	closed       bool
+}
+
+func newDescriptionBuffer(maxLines int, spillEnabled bool, spillDir string) *descriptionBuffer {
+	return &descriptionBuffer{
+		maxLines:     maxLines,
+		spillEnabled: spillEnabled,
+		spillDir:     spillDir,
+	}
+}
+
+// length returns the amount of lines ever written including the ones that didn't fit in memory.
+func (buffer *descriptionBuffer) length() int {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	return buffer.evicted + len(buffer.lines)
+}
+
+func (buffer *descriptionBuffer) line(index int) string {
+	return buffer.lines[(buffer.start+index)%len(buffer.lines)]
+}
+
+// appendText appends text to the last line and adds new lines for every line break in it. Lines belong to
+// the text that started them, verbose lines can be left out when the output is read.
+func (buffer *descriptionBuffer) appendText(text string, verbose bool) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	linesToAppend := strings.Split(text, "\n")
+	if len(buffer.lines) > 0 {
+		last := (buffer.start + len(buffer.lines) - 1) % len(buffer.lines)
+		if buffer.lines[last] == "" && linesToAppend[0] != "" {
+			// the line was only started by the line break of the previous text
+			buffer.markVerbose(buffer.evicted+len(buffer.lines)-1, verbose)
+		}
+		buffer.lines[last] += linesToAppend[0]
+		linesToAppend = linesToAppend[1:]
+	}
+	for _, line := range linesToAppend {
+		buffer.markVerbose(buffer.evicted+len(buffer.lines), verbose)
+		buffer.push(line)
+	}
+}
+
+// markVerbose sets whether the last or the next line is verbose.
+func (buffer *descriptionBuffer) markVerbose(index int, verbose bool) {
+	last := len(buffer.verbose) - 1
+	switch {
+	case last >= 0 && buffer.verbose[last] == index && !verbose:
+		buffer.verbose = buffer.verbose[:last]
+	case (last < 0 || buffer.verbose[last] < index) && verbose:
+		buffer.verbose = append(buffer.verbose, index)
+	}
+}
+
+// isVerbose reports if the line is verbose, the index of the first verbose line at or after the line is moved
+// past it, so lines are checked in order without searching.
+func (buffer *descriptionBuffer) isVerbose(index int, next *int) bool {
+	for *next < len(buffer.verbose) && buffer.verbose[*next] < index {
+		*next++
+	}
+	return *next < len(buffer.verbose) && buffer.verbose[*next] == index
+}
+
+func (buffer *descriptionBuffer) push(line string) {
+	if buffer.maxLines <= 0 || len(buffer.lines) < buffer.maxLines {
+		buffer.lines = append(buffer.lines, line)
+		return
+	}
+	buffer.spill(buffer.lines[buffer.start])
+	buffer.evicted++
+	buffer.lines[buffer.start] = line
+	buffer.start = (buffer.start + 1) % len(buffer.lines)
+}
+
+func (buffer *descriptionBuffer) spill(line string) {
+	if !buffer.spillEnabled || buffer.closed {
+		return
+	}
+	if buffer.spillFile == nil {
+		if buffer.evicted > 0 {
+			// some lines were already dropped so there is no point in keeping the rest
+			return
+		}
+		file, err := ioutil.TempFile(buffer.spillDir, "echelon-*.log")
+		if err != nil {
+			buffer.spillEnabled = false
+			return
+		}
+		buffer.spillFile = file
+		buffer.spillWriter = bufio.NewWriter(file)
+	}
+	n, err := buffer.spillWriter.WriteString(line + "\n")
+	buffer.spilledBytes += int64(n)
+	if err != nil {
+		buffer.spillEnabled = false
+		buffer.removeSpillFile()
+	}
+}
+
+// tail returns a copy of the last count lines that are still in memory. Negative count means all of them.
+func (buffer *descriptionBuffer) tail(count int) []string {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	if count < 0 || count > len(buffer.lines) {
+		count = len(buffer.lines)
+	}
+	result := make([]string, 0, count)
+	for i := len(buffer.lines) - count; i < len(buffer.lines); i++ {
+		result = append(result, buffer.line(i))
+	}
+	return result
+}
+
+// tailWithoutVerbose returns a copy of the last count lines among the first upTo ones that are still in memory
+// and aren't verbose. Negative count means all of them.
+func (buffer *descriptionBuffer) tailWithoutVerbose(count int, upTo int) []string {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	if upTo > buffer.evicted+len(buffer.lines) {
+		upTo = buffer.evicted + len(buffer.lines)
+	}
+	var result []string
+	next := len(buffer.verbose) - 1
+	for i := upTo - 1; i >= buffer.evicted && (count < 0 || len(result) < count); i-- {
+		for next >= 0 && buffer.verbose[next] > i {
+			next--
+		}
+		if next < 0 || buffer.verbose[next] != i {
+			result = append(result, buffer.line(i-buffer.evicted))
+		}
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// writeTo writes the first upTo lines of the output separated by line breaks, verbose lines only if asked to.
+func (buffer *descriptionBuffer) writeTo(w io.Writer, upTo int, verbose bool) error {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	if upTo > buffer.evicted+len(buffer.lines) {
+		upTo = buffer.evicted + len(buffer.lines)
+	}
+	if !verbose && len(buffer.verbose) > 0 {
+		return buffer.writeWithoutVerboseTo(w, upTo)
+	}
+	if buffer.evicted > 0 {
+		if err := buffer.writeSpilledTo(w, upTo); err != nil {
+			return err
+		}
+	}
+	for i := buffer.evicted; i < upTo; i++ {
+		if i > buffer.evicted {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, buffer.line(i-buffer.evicted)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (buffer *descriptionBuffer) writeSpilledTo(w io.Writer, upTo int) error {
+	if buffer.spillFile == nil {
+		return ErrOutputNotAvailable
+	}
+	if err := buffer.spillWriter.Flush(); err != nil {
+		return err
+	}
+	spilled, err := os.Open(buffer.spillFile.Name())
+	if err != nil {
+		return err
+	}
+	defer spilled.Close()
+	if upTo > buffer.evicted {
+		// in-memory lines follow so the whole file can be copied as is
+		_, err = io.CopyN(w, spilled, buffer.spilledBytes)
+		return err
+	}
+	reader := bufio.NewReader(spilled)
+	for i := 0; i < upTo; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if i == upTo-1 {
+			line = strings.TrimSuffix(line, "\n")
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeWithoutVerboseTo writes the lines that aren't verbose one by one.
+func (buffer *descriptionBuffer) writeWithoutVerboseTo(w io.Writer, upTo int) error {
+	next := 0
+	written := 0
+	write := func(index int, line string) error {
+		if buffer.isVerbose(index, &next) {
+			return nil
+		}
+		if written > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		written++
+		_, err := io.WriteString(w, line)
+		return err
+	}
+	if buffer.evicted > 0 && upTo > 0 {
+		if buffer.spillFile == nil {
+			return ErrOutputNotAvailable
+		}
+		if err := buffer.spillWriter.Flush(); err != nil {
+			return err
+		}
+		spilled, err := os.Open(buffer.spillFile.Name())
+		if err != nil {
+			return err
+		}
+		defer spilled.Close()
+		reader := bufio.NewReader(spilled)
+		for i := 0; i < buffer.evicted && i < upTo; i++ {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			if err := write(i, strings.TrimSuffix(line, "\n")); err != nil {
+				return err
+			}
+		}
+	}
+	for i := buffer.evicted; i < upTo; i++ {
+		if err := write(i, buffer.line(i-buffer.evicted)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close removes the spill file. Lines that don't fit in memory are dropped afterwards.
+func (buffer *descriptionBuffer) close() {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	buffer.closed = true
+	buffer.removeSpillFile()
+}
+
+func (buffer *descriptionBuffer) removeSpillFile() {
+	if buffer.spillFile == nil {
+		return
+	}
+	_ = buffer.spillFile.Close()
+	_ = os.Remove(buffer.spillFile.Name())
+	buffer.spillFile = nil
+	buffer.spillWriter = nil
+}
@@ -0,0 +1,111 @@
+//nolint:testpackage
+package node
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_descriptionBuffer_KeepsLastLines(t *testing.T) {
+	t.Parallel()
+	buffer := newDescriptionBuffer(3, false, "")
+	buffer.appendText("one\ntwo\nthree\nfour\nfi", false)
+	buffer.appendText("ve", false)
+	assert.Equal(t, 5, buffer.length())
+	assert.Equal(t, []string{"three", "four", "five"}, buffer.tail(-1))
+	assert.Equal(t, []string{"five"}, buffer.tail(1))
+	assert.True(t, errors.Is(buffer.writeTo(&bytes.Buffer{}, 5, true), ErrOutputNotAvailable))
+}
+
+func Test_descriptionBuffer_SpillsToDisk(t *testing.T) {
+	t.Parallel()
+	spillDir := t.TempDir()
+	buffer := newDescriptionBuffer(2, true, spillDir)
+	text := "one\ntwo\nthree\nfour\nfive\n"
+	for _, chunk := range strings.SplitAfter(text, "e") {
+		buffer.appendText(chunk, false)
+	}
+	assert.Equal(t, []string{"five", ""}, buffer.tail(-1))
+
+	var full bytes.Buffer
+	require.NoError(t, buffer.writeTo(&full, buffer.length(), true))
+	assert.Equal(t, text, full.String())
+
+	var partial bytes.Buffer
+	require.NoError(t, buffer.writeTo(&partial, 2, true))
+	assert.Equal(t, "one\ntwo", partial.String())
+
+	files, err := filepath.Glob(filepath.Join(spillDir, "*"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+	buffer.close()
+	_, err = os.Stat(files[0])
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_Snapshot_FullDescriptionIsConsistent(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	testConfig.MaxDescriptionLines = 2
+	testConfig.SpillDescriptionToDisk = true
+	testConfig.SpillDirectory = t.TempDir()
+	root := NewEchelonNode("root", testConfig)
+	child := root.StartNewChild("child")
+	child.AppendDescription("one\ntwo\nthree")
+	snapshot := root.Snapshot().FindChild("child")
+	child.AppendDescription("\nfour\nfive")
+
+	var before bytes.Buffer
+	require.NoError(t, snapshot.WriteFullDescription(&before))
+	assert.Equal(t, "one\ntwo\nthree", before.String())
+	var after bytes.Buffer
+	require.NoError(t, child.WriteFullDescription(&after))
+	assert.Equal(t, "one\ntwo\nthree\nfour\nfive", after.String())
+	root.Snapshot().ReleaseOutput()
+}
+
+func Test_descriptionBuffer_LeavesOutVerboseLines(t *testing.T) {
+	t.Parallel()
+	buffer := newDescriptionBuffer(2, true, t.TempDir())
+	defer buffer.close()
+	buffer.appendText("one\n", false)
+	buffer.appendText("debug\n", true)
+	buffer.appendText("two\nthr", false)
+	buffer.appendText("ee\n", true)
+	buffer.appendText("trace\n", true)
+	buffer.appendText("four\n", false)
+
+	var full bytes.Buffer
+	require.NoError(t, buffer.writeTo(&full, buffer.length(), true))
+	assert.Equal(t, "one\ndebug\ntwo\nthree\ntrace\nfour\n", full.String())
+	var filtered bytes.Buffer
+	require.NoError(t, buffer.writeTo(&filtered, buffer.length(), false))
+	assert.Equal(t, "one\ntwo\nthree\nfour\n", filtered.String())
+	var partial bytes.Buffer
+	require.NoError(t, buffer.writeTo(&partial, 3, false))
+	assert.Equal(t, "one\ntwo", partial.String())
+	assert.Equal(t, []string{"four", ""}, buffer.tailWithoutVerbose(-1, buffer.length()))
+	assert.Equal(t, []string{"four"}, buffer.tailWithoutVerbose(-1, buffer.length()-1))
+}
+
+func Test_Snapshot_VisibleDescriptionWithoutVerbose(t *testing.T) {
+	t.Parallel()
+	root := NewEchelonNode("root", newTestConfig())
+	child := root.StartNewChild("child")
+	child.SetVisibleDescriptionLines(2)
+	child.AppendDescription("one\ntwo\n")
+	child.AppendVerboseDescription("debug\n")
+	child.AppendDescription("three\n")
+	child.AppendVerboseDescription("trace\n")
+	child.Complete()
+	snapshot := root.Snapshot().FindChild("child")
+	assert.Equal(t, []string{"three", "trace"}, snapshot.VisibleDescription())
+	assert.Equal(t, []string{"two", "three"}, snapshot.VisibleDescriptionWithoutVerbose())
+}
@@ -1,28 +1,37 @@
 package node
 
 import (
-	"fmt"
-	"github.com/cirruslabs/echelon/renderers/config"
-	"github.com/cirruslabs/echelon/terminal"
-	"github.com/cirruslabs/echelon/utils"
-	"golang.org/x/text/width"
+	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/cirruslabs/echelon/renderers/config"
 )
 
+// EchelonNode is a mutable node of the tree. It's not safe for concurrent use: the tree is modified by a single
+// owner goroutine while everybody else reads immutable snapshots produced by Snapshot.
 type EchelonNode struct {
-	lock                    sync.RWMutex
+	id                      uint64
 	done                    sync.WaitGroup
 	status                  string
 	title                   string
 	titleColor              int
-	description             []string
+	description             *descriptionBuffer
 	visibleDescriptionLines int
 	config                  *config.InteractiveRendererConfig
 	startTime               time.Time
 	endTime                 time.Time
+	warnings                int
+	errors                  int
+	badges                  []badge
 	children                []*EchelonNode
+	parent                  *EchelonNode
+	dirty                   bool
+	version                 uint64
+	snapshot                *Snapshot
+	templateErrors          *templateErrors // shared by the whole tree
 }
 
 func StartNewEchelonNode(title string, config *config.InteractiveRendererConfig) *EchelonNode {
@@ -31,45 +40,149 @@ func StartNewEchelonNode(title string, config *config.InteractiveRendererConfig)
 	return result
 }
 
+// pendingStatus is shown until the node completes.
+const pendingStatus = "⏸"
+
+// lastNodeID is the ID of the most recently created node.
+var lastNodeID uint64
+
 func NewEchelonNode(title string, config *config.InteractiveRendererConfig) *EchelonNode {
 	zeroTime := time.Time{}
+	description := newDescriptionBuffer(config.MaxDescriptionLines, config.SpillDescriptionToDisk, config.SpillDirectory)
 	result := &EchelonNode{
-		status:                  "⏸",
+		id:                      atomic.AddUint64(&lastNodeID, 1),
+		status:                  pendingStatus,
 		title:                   title,
-		titleColor:              config.Colors.NeutralColor,
-		description:             make([]string, 0),
+		titleColor:              config.ColorSchema().NeutralColor,
+		description:             description,
 		visibleDescriptionLines: config.VisibleDescriptionLines,
 		config:                  config,
 		startTime:               zeroTime,
 		endTime:                 zeroTime,
 		children:                make([]*EchelonNode, 0),
+		dirty:                   true,
+		templateErrors:          &templateErrors{},
 	}
 	result.done.Add(1)
 	return result
 }
 
+// Snapshot returns an immutable copy of the node and its children. Unmodified subtrees are shared between snapshots.
+func (node *EchelonNode) Snapshot() *Snapshot {
+	if !node.dirty && node.snapshot != nil {
+		return node.snapshot
+	}
+	children := make([]*Snapshot, 0, len(node.children))
+	for _, child := range node.children {
+		children = append(children, child.Snapshot())
+	}
+	if node.config.GroupKey != nil {
+		children = groupChildren(children, node.path(), node.config)
+	}
+	node.version++
+	description, descriptionOmitted := node.visibleDescription()
+	node.snapshot = &Snapshot{
+		id:                      node.id,
+		version:                 node.version,
+		status:                  node.status,
+		title:                   node.title,
+		titleColor:              node.titleColor,
+		description:             description,
+		descriptionLength:       node.description.length(),
+		descriptionOmitted:      descriptionOmitted,
+		descriptionBuffer:       node.description,
+		visibleDescriptionLines: node.visibleDescriptionLines,
+		config:                  node.config,
+		startTime:               node.startTime,
+		endTime:                 node.endTime,
+		warnings:                node.warnings,
+		errors:                  node.errors,
+		badges:                  node.badges,
+		children:                children,
+		templateErrors:          node.templateErrors,
+	}
+	node.snapshot.failedWithin = node.snapshot.hasFailed() || anyFailedWithin(children)
+	if node.config.TitleTemplate != nil {
+		node.snapshot.path = node.path()
+	}
+	node.dirty = false
+	return node.snapshot
+}
+
+// visibleDescription returns the last lines of output to show and whether some of the lines are omitted. Once
+// the node completes the empty line after the last line break isn't counted, so the visible lines are all filled.
+func (node *EchelonNode) visibleDescription() ([]string, bool) {
+	count := node.visibleDescriptionLines
+	length := node.description.length()
+	if !node.HasCompleted() || count < 0 {
+		result := node.description.tail(count)
+		return result, length > len(result)
+	}
+	result := node.description.tail(count + 1)
+	if len(result) > 0 && result[len(result)-1] == "" {
+		length--
+		result = result[:len(result)-1]
+	} else if len(result) > count {
+		result = result[1:]
+	}
+	return result, length > len(result)
+}
+
+// markDirty makes sure the next snapshot of the node and all of its ancestors will be rebuilt.
+func (node *EchelonNode) markDirty() {
+	for n := node; n != nil && !n.dirty; n = n.parent {
+		n.dirty = true
+	}
+}
+
 func (node *EchelonNode) GetChildren() []*EchelonNode {
-	node.lock.RLock()
-	defer node.lock.RUnlock()
 	return node.children
 }
 
 func (node *EchelonNode) UpdateTitle(text string) {
-	node.lock.Lock()
-	defer node.lock.Unlock()
 	node.title = text
+	node.markDirty()
 }
 
 func (node *EchelonNode) UpdateConfig(config *config.InteractiveRendererConfig) {
-	node.lock.Lock()
-	defer node.lock.Unlock()
 	node.config = config
+	node.markDirty()
 }
 
 func (node *EchelonNode) ClearAllChildren() {
-	node.lock.Lock()
-	defer node.lock.Unlock()
+	for _, child := range node.children {
+		child.parent = nil
+		child.releaseOutput()
+	}
 	node.children = make([]*EchelonNode, 0)
+	node.markDirty()
+}
+
+// Remove detaches the node from its parent and removes its spilled output.
+func (node *EchelonNode) Remove() {
+	parent := node.parent
+	if parent == nil {
+		return
+	}
+	for i, child := range parent.children {
+		if child == node {
+			children := make([]*EchelonNode, 0, len(parent.children)-1)
+			children = append(children, parent.children[:i]...)
+			parent.children = append(children, parent.children[i+1:]...)
+			break
+		}
+	}
+	node.parent = nil
+	node.releaseOutput()
+	parent.markDirty()
+}
+
+// releaseOutput removes spilled output of the node and all of its descendants.
+func (node *EchelonNode) releaseOutput() {
+	node.description.close()
+	for _, child := range node.children {
+		child.releaseOutput()
+	}
 }
 
 func (node *EchelonNode) ClearDescription() {
@@ -77,101 +190,48 @@ func (node *EchelonNode) ClearDescription() {
 }
 
 func (node *EchelonNode) SetDescription(description []string) {
-	node.lock.Lock()
-	defer node.lock.Unlock()
-	node.description = description
+	node.description.close()
+	node.description = newDescriptionBuffer(node.config.MaxDescriptionLines, node.config.SpillDescriptionToDisk,
+		node.config.SpillDirectory)
+	if len(description) > 0 {
+		node.description.appendText(strings.Join(description, "\n"), false)
+	}
+	node.markDirty()
 }
 
 func (node *EchelonNode) SetVisibleDescriptionLines(count int) {
-	node.lock.Lock()
-	defer node.lock.Unlock()
 	node.visibleDescriptionLines = count
+	node.markDirty()
 }
 
+// DescriptionLength returns the amount of lines of output including the ones that didn't fit in memory.
 func (node *EchelonNode) DescriptionLength() int {
-	node.lock.RLock()
-	defer node.lock.RUnlock()
-	return len(node.description)
-}
-
-func (node *EchelonNode) Render() []string {
-	node.lock.RLock()
-	defer node.lock.RUnlock()
-	title := node.fancyTitle()
-	tail := node.renderChildren()
-	if len(node.description) > node.visibleDescriptionLines && node.visibleDescriptionLines >= 0 {
-		tail = append(tail, "...")
-		tail = append(tail, node.description[(len(node.description)-node.visibleDescriptionLines):]...)
-	} else {
-		tail = append(tail, node.description...)
-	}
-	indent := "  " // two spaces by default
-	props, _ := width.LookupString(title)
-	if props.Kind() == width.EastAsianWide || props.Kind() == width.EastAsianFullwidth {
-		indent = "   " // three spaces since title start with a wide emoji
-	}
-	result := []string{title}
-	for _, descriptionLine := range tail {
-		result = append(result, indent+descriptionLine)
-	}
-
-	return result
+	return node.description.length()
 }
 
-func (node *EchelonNode) renderChildren() []string {
-	var result []string
-	for _, child := range node.children {
-		result = append(result, child.Render()...)
-	}
-	return result
+// WriteFullDescription writes the whole output of the node including lines spilled to disk.
+func (node *EchelonNode) WriteFullDescription(w io.Writer) error {
+	return node.description.writeTo(w, node.description.length(), true)
 }
 
-func (node *EchelonNode) fancyTitle() string {
-	duration := utils.FormatDuration(node.executionDuration(), len(node.children) == 0)
-	isRunning := node.isRunning()
-	prefix := node.status
-	if isRunning {
-		prefix = node.config.CurrentProgressIndicatorFrame()
-	}
-	coloredTitle := node.title
-	if node.titleColor >= 0 {
-		coloredTitle = terminal.GetColoredText(node.titleColor, node.title)
-	}
-	return fmt.Sprintf("%s %s %s", prefix, coloredTitle, duration)
+// Render is a shortcut for rendering the current snapshot of the node.
+func (node *EchelonNode) Render(width int) []string {
+	return node.Snapshot().Render(width)
 }
 
 func (node *EchelonNode) ExecutionDuration() time.Duration {
-	node.lock.RLock()
-	defer node.lock.RUnlock()
-	return node.executionDuration()
-}
-
-func (node *EchelonNode) executionDuration() time.Duration {
-	if !node.startTime.IsZero() && node.endTime.IsZero() {
-		return time.Since(node.startTime)
-	}
-	return node.endTime.Sub(node.startTime)
+	return executionDuration(node.startTime, node.endTime)
 }
 
 func (node *EchelonNode) HasStarted() bool {
-	node.lock.RLock()
-	defer node.lock.RUnlock()
 	return !node.startTime.IsZero()
 }
 
 func (node *EchelonNode) HasCompleted() bool {
-	node.lock.RLock()
-	defer node.lock.RUnlock()
 	return !node.endTime.IsZero()
 }
 
 func (node *EchelonNode) IsRunning() bool {
-	node.lock.RLock()
-	defer node.lock.RUnlock()
-	return node.isRunning()
-}
-
-func (node *EchelonNode) isRunning() bool {
 	return !node.startTime.IsZero() && node.endTime.IsZero()
 }
 
@@ -182,8 +242,6 @@ func (node *EchelonNode) StartNewChild(childName string) *EchelonNode {
 }
 
 func (node *EchelonNode) FindOrCreateChild(childTitle string) *EchelonNode {
-	node.lock.Lock()
-	defer node.lock.Unlock()
 	// look from the end since this is a common pattern to get the last child
 	for i := len(node.children) - 1; i >= 0; i-- {
 		child := node.children[i]
@@ -192,85 +250,124 @@ func (node *EchelonNode) FindOrCreateChild(childTitle string) *EchelonNode {
 		}
 	}
 	child := NewEchelonNode(childTitle, node.config)
-	node.children = append(node.children, child)
+	node.AddNewChild(child)
 	return child
 }
 
 func (node *EchelonNode) AddNewChild(child *EchelonNode) {
-	node.lock.Lock()
-	defer node.lock.Unlock()
+	child.parent = node
+	child.templateErrors = node.templateErrors
 	node.children = append(node.children, child)
+	node.markDirty()
 }
 
 func (node *EchelonNode) Start() {
-	node.lock.Lock()
-	defer node.lock.Unlock()
 	if node.startTime.IsZero() {
 		node.startTime = time.Now()
+		node.markDirty()
 	}
 }
 
 func (node *EchelonNode) CompleteWithColor(status string, titleColor int) {
-	if !node.endTime.IsZero() {
+	if node.HasCompleted() {
 		return
 	}
-	node.lock.Lock()
-	defer node.lock.Unlock()
-	node.endTime = time.Now()
-	if node.startTime.IsZero() {
-		node.startTime = node.endTime
-	}
 	node.status = status
 	node.titleColor = titleColor
-	node.done.Done()
+	node.Complete()
 }
 
 func (node *EchelonNode) Complete() {
-	if !node.endTime.IsZero() {
+	if node.HasCompleted() {
 		return
 	}
-	node.lock.Lock()
-	defer node.lock.Unlock()
 	node.endTime = time.Now()
 	if node.startTime.IsZero() {
 		node.startTime = node.endTime
 	}
+	node.markDirty()
 	node.done.Done()
 }
 
 func (node *EchelonNode) SetTitleColor(ansiColor int) {
-	node.lock.Lock()
-	defer node.lock.Unlock()
 	node.titleColor = ansiColor
+	node.markDirty()
 }
 
 func (node *EchelonNode) SetStatus(text string) {
-	node.lock.Lock()
-	defer node.lock.Unlock()
 	node.status = text
+	node.markDirty()
 }
 
+// TakeTemplateErrors returns errors of executing the title template in the tree that weren't taken yet, each
+// distinct error once. Unlike other methods it can be called from any goroutine.
+func (node *EchelonNode) TakeTemplateErrors() []error {
+	return node.templateErrors.take()
+}
+
+// WaitCompletion blocks until the node is completed. Unlike other methods it can be called from any goroutine.
 func (node *EchelonNode) WaitCompletion() {
 	node.done.Wait()
 }
 
 func (node *EchelonNode) AppendDescription(text string) {
+	node.appendDescription(text, false)
+}
+
+// AppendVerboseDescription appends output that can be left out when it's read, e.g. debug messages.
+func (node *EchelonNode) AppendVerboseDescription(text string) {
+	node.appendDescription(text, true)
+}
+
+func (node *EchelonNode) appendDescription(text string, verbose bool) {
 	if node.HasCompleted() {
 		return
 	}
-	node.lock.Lock()
-	defer node.lock.Unlock()
-	linesToAppend := strings.Split(text, "\n")
-	if len(linesToAppend) == 0 {
+	node.description.appendText(text, verbose)
+	node.markDirty()
+}
+
+// CountMessages adds to the amount of lines logged at the warning and the error levels.
+func (node *EchelonNode) CountMessages(warnings int, errors int) {
+	if node.HasCompleted() || (warnings == 0 && errors == 0) {
 		return
 	}
-	if len(node.description) == 0 {
-		node.description = linesToAppend
-		return
+	node.warnings += warnings
+	node.errors += errors
+	node.markDirty()
+}
+
+// badge is a short label shown next to the title.
+type badge struct {
+	name string
+	text string
+}
+
+// SetBadge adds, replaces or, if the text is empty, removes a badge keeping the order in which badges were added.
+func (node *EchelonNode) SetBadge(name string, text string) {
+	// snapshots share the slice so it's never modified in place
+	badges := make([]badge, 0, len(node.badges)+1)
+	found := false
+	for _, b := range node.badges {
+		if b.name != name {
+			badges = append(badges, b)
+			continue
+		}
+		found = true
+		if text != "" {
+			badges = append(badges, badge{name: name, text: text})
+		}
+	}
+	if !found && text != "" {
+		badges = append(badges, badge{name: name, text: text})
 	}
-	// append first new line to the last one
-	node.description[len(node.description)-1] = node.description[len(node.description)-1] + linesToAppend[0]
-	if len(linesToAppend) > 1 {
-		node.description = append(node.description, linesToAppend[1:]...)
+	node.badges = badges
+	node.markDirty()
+}
+
+func executionDuration(startTime time.Time, endTime time.Time) time.Duration {
+	if !startTime.IsZero() && endTime.IsZero() {
+		return time.Since(startTime)
 	}
+	return endTime.Sub(startTime)
 }
@@ -0,0 +1,309 @@
+//nolint:testpackage
+package node
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfig() *config.InteractiveRendererConfig {
+	result := config.NewDefaultSymbolsOnlyRenderingConfig()
+	result.VisibleDescriptionLines = 5
+	return result
+}
+
+// newTestTree creates a tree with parentsCount children of the root each having childrenCount completed children.
+func newTestTree(parentsCount int, childrenCount int, completeParents bool) *EchelonNode {
+	testConfig := newTestConfig()
+	root := NewEchelonNode("root", testConfig)
+	for i := 0; i < parentsCount; i++ {
+		parent := root.StartNewChild(fmt.Sprintf("Parent %d", i))
+		for j := 0; j < childrenCount; j++ {
+			child := parent.StartNewChild(fmt.Sprintf("Child %d", j))
+			child.AppendDescription("Some output\nMore output")
+			child.CompleteWithColor(testConfig.SuccessStatus, testConfig.Colors.SuccessColor)
+		}
+		if completeParents {
+			parent.CompleteWithColor(testConfig.SuccessStatus, testConfig.Colors.SuccessColor)
+		}
+	}
+	return root
+}
+
+func invalidateAll(node *EchelonNode) {
+	node.snapshot = nil
+	for _, child := range node.children {
+		invalidateAll(child)
+	}
+}
+
+func Test_ClearAllChildren_DetachesChildren(t *testing.T) {
+	t.Parallel()
+	root := newTestTree(1, 2, false)
+	parent := root.GetChildren()[0]
+	child := parent.GetChildren()[0]
+	parent.ClearAllChildren()
+	assert.Nil(t, child.parent)
+	before := root.Snapshot()
+
+	// changes of a detached child don't reach its former ancestors
+	child.SetStatus("?")
+	child.Remove()
+	assert.Same(t, before, root.Snapshot())
+	assert.Empty(t, parent.GetChildren())
+}
+
+func Test_Render_CachedLinesFollowModifications(t *testing.T) {
+	t.Parallel()
+	root := newTestTree(2, 2, true)
+	root.Complete()
+	before := root.Render(80)
+	assert.Equal(t, before, root.Render(80))
+	assert.Contains(t, before, "      Some output")
+
+	deepChild := root.GetChildren()[1].GetChildren()[0]
+	deepChild.UpdateTitle("Renamed")
+	after := root.Render(80)
+	assert.NotEqual(t, before, after)
+	assert.Contains(t, after[9], "Renamed")
+
+	root.GetChildren()[0].FindOrCreateChild("Late child")
+	assert.Len(t, root.Render(80), len(after)+1)
+}
+
+func Test_Render_RunningNodeKeepsTail(t *testing.T) {
+	t.Parallel()
+	root := newTestTree(1, 3, false)
+	parent := root.GetChildren()[0]
+	snapshot := parent.Snapshot()
+	snapshot.Render(80)
+	assert.True(t, snapshot.cache.hasTail)
+	assert.False(t, snapshot.cache.hasLines)
+
+	parent.AppendDescription("Parent output")
+	assert.Equal(t, "  Parent output", parent.Render(80)[len(parent.Render(80))-1])
+}
+
+func benchmarkRender(b *testing.B, root *EchelonNode, cached bool) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !cached {
+			invalidateAll(root)
+		}
+		root.Render(120)
+	}
+}
+
+func BenchmarkRender_Completed10k_Uncached(b *testing.B) {
+	benchmarkRender(b, newTestTree(100, 100, true), false)
+}
+
+func BenchmarkRender_Completed10k_Cached(b *testing.B) {
+	benchmarkRender(b, newTestTree(100, 100, true), true)
+}
+
+func BenchmarkRender_Running10k_Uncached(b *testing.B) {
+	benchmarkRender(b, newTestTree(100, 100, false), false)
+}
+
+func BenchmarkRender_Running10k_Cached(b *testing.B) {
+	benchmarkRender(b, newTestTree(100, 100, false), true)
+}
+
+func Test_Snapshot_IsImmutable(t *testing.T) {
+	t.Parallel()
+	root := newTestTree(1, 1, false)
+	parent := root.GetChildren()[0]
+	parent.AppendDescription("foo")
+	before := root.Snapshot()
+	beforeLines := before.Render(80)
+	assert.Same(t, before, root.Snapshot(), "unmodified tree should reuse the snapshot")
+
+	parent.AppendDescription("bar")
+	parent.StartNewChild("New child")
+	after := root.Snapshot()
+	assert.Greater(t, after.Version(), before.Version())
+	assert.Same(t, before.GetChildren()[0].GetChildren()[0], after.GetChildren()[0].GetChildren()[0],
+		"unmodified subtrees should be shared")
+	assert.Equal(t, beforeLines, before.Render(80))
+	assert.Equal(t, "  foobar", after.GetChildren()[0].Render(80)[5])
+}
+
+func Test_Render_TruncatesLinesToWidth(t *testing.T) {
+	t.Parallel()
+	testConfig := config.NewDefaultEmojiRenderingConfig()
+	root := NewEchelonNode("root", testConfig)
+	parent := root.StartNewChild("A parent with a very long title")
+	child := parent.StartNewChild("Child")
+	child.AppendDescription("日本語 output that doesn't fit\nshort")
+	child.CompleteWithColor(testConfig.SuccessStatus, testConfig.Colors.SuccessColor)
+
+	lines := parent.Render(16)
+	for _, line := range lines {
+		assert.LessOrEqual(t, terminal.StringWidth(line), 16, line)
+	}
+	assert.Equal(t, "      日本語 ou…", lines[2])
+	assert.Equal(t, "      short", lines[3])
+}
+
+func newTreeLayoutNode(testConfig *config.InteractiveRendererConfig) *EchelonNode {
+	root := NewEchelonNode("root", testConfig)
+	parent := root.StartNewChild("parent")
+	first := parent.StartNewChild("first")
+	first.StartNewChild("nested").CompleteWithColor(testConfig.SuccessStatus, -1)
+	first.AppendDescription("output")
+	first.CompleteWithColor(testConfig.SuccessStatus, -1)
+	parent.StartNewChild("second").CompleteWithColor(testConfig.FailureStatus, -1)
+	parent.CompleteWithColor(testConfig.FailureStatus, -1)
+	return parent
+}
+
+func Test_Render_TreeLayout(t *testing.T) {
+	t.Parallel()
+	testConfig := config.NewDefaultEmojiRenderingConfig()
+	testConfig.Layout = config.LayoutTree
+	lines := newTreeLayoutNode(testConfig).Render(0)
+	assert.Equal(t, []string{
+		"❌ parent 0s",
+		"├─ ✅ first 0s",
+		"│  └─ ✅ nested 0.0s",
+		"│     output",
+		"└─ ❌ second 0.0s",
+	}, lines)
+}
+
+func Test_Render_ASCIITreeLayoutWithIndentWidth(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	testConfig.Layout = config.LayoutASCIITree
+	testConfig.IndentWidth = 4
+	lines := newTreeLayoutNode(testConfig).Render(0)
+	assert.Equal(t, []string{
+		"- parent 0s",
+		"|-- + first 0s",
+		"|   `-- + nested 0.0s",
+		"|       output",
+		"`-- - second 0.0s",
+	}, lines)
+}
+
+func Test_Render_IndentWidth(t *testing.T) {
+	t.Parallel()
+	testConfig := config.NewDefaultEmojiRenderingConfig()
+	testConfig.IndentWidth = 2
+	lines := newTreeLayoutNode(testConfig).Render(0)
+	assert.Equal(t, "    ✅ nested 0.0s", lines[2])
+	assert.Equal(t, "    output", lines[3])
+}
+
+func Test_Render_ColumnLayout(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	testConfig.Colors = terminal.NoColorSchema()
+	testConfig.ColumnLayout = true
+	root := NewEchelonNode("root", testConfig)
+	parent := root.StartNewChild("parent")
+	short := parent.StartNewChild("short")
+	short.SetBadge("version", "v1.2")
+	short.CountMessages(2, 1)
+	short.CompleteWithColor(testConfig.SuccessStatus, terminal.NoColor)
+	long := parent.StartNewChild("a child with a title that doesn't fit")
+	long.SetBadge("host", "linux")
+	long.SetBadge("version", "v10")
+	long.SetBadge("host", "")
+	long.CountMessages(1, 0)
+	long.CompleteWithColor(testConfig.SuccessStatus, terminal.NoColor)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	short.startTime, short.endTime = start, start.Add(1500*time.Millisecond)
+	long.startTime, long.endTime = start, start.Add(75*time.Second)
+	parent.startTime = start
+	parent.CompleteWithColor(testConfig.FailureStatus, terminal.NoColor)
+	parent.endTime = start.Add(80 * time.Second)
+
+	lines := parent.Render(60)
+	assert.Equal(t, []string{
+		"- parent                                           2/2 01:20",
+		"  + short                    [v1.2] 2 warnings 1 error  1.5s",
+		"  + a child with a title th…  [v10]  1 warning         01:15",
+	}, lines)
+	for _, line := range lines {
+		assert.Equal(t, 60, terminal.StringWidth(line), line)
+	}
+}
+
+func Test_Render_ColumnLayoutWithoutSpace(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	testConfig.ColumnLayout = true
+	root := NewEchelonNode("root", testConfig)
+	child := root.StartNewChild("child")
+	child.SetBadge("version", "a very long badge")
+	child.CompleteWithColor(testConfig.SuccessStatus, terminal.NoColor)
+	// columns are dropped in favor of the title
+	assert.Equal(t, "+ child 0.0s", terminal.Truncate(child.Render(20)[0], 20, ""))
+	// unknown width keeps the usual layout
+	assert.Equal(t, "+ child 0.0s", terminal.Truncate(child.Render(0)[0], 100, ""))
+}
+
+func Test_TitleTemplate(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	testConfig.Colors = terminal.NoColorSchema()
+	testConfig.TitleTemplate = template.Must(template.New("title").Funcs(template.FuncMap{"join": strings.Join}).Parse(
+		"{{.Status}} {{join .Path \"/\"}} ({{.Depth}}) {{.Completed}}/{{.Total}}{{with .Fields}} {{.host}}{{end}}\n"))
+	root := NewEchelonNode("root", testConfig)
+	parent := root.StartNewChild("parent")
+	parent.SetBadge("host", "alpha")
+	parent.StartNewChild("done").CompleteWithColor(testConfig.SuccessStatus, -1)
+	parent.StartNewChild("running")
+	parent.CompleteWithColor(testConfig.FailureStatus, -1)
+	lines := root.Snapshot().FindChild("parent").Render(0)
+	assert.Equal(t, "- parent (1) 1/2 alpha ", lines[0])
+	assert.Equal(t, "  + parent/done (2) 0/0 ", lines[1])
+}
+
+func Test_TitleTemplateErrors(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	testConfig.Colors = terminal.NoColorSchema()
+	testConfig.TitleTemplate = template.Must(template.New("title").Parse("{{index .Path 1}}"))
+	root := NewEchelonNode("root", testConfig)
+	root.StartNewChild("first")
+	root.StartNewChild("second").StartNewChild("nested")
+	snapshot := root.Snapshot()
+	// titles fall back to the default ones unless the template works for them
+	assert.Contains(t, snapshot.FindChild("first").Render(0)[0], " first ")
+	lines := snapshot.FindChild("second").Render(0)
+	assert.Contains(t, lines[0], " second ")
+	assert.Equal(t, "  nested", lines[1])
+	errs := root.TakeTemplateErrors()
+	require.Len(t, errs, 1, "the same error is reported once")
+	assert.Contains(t, errs[0].Error(), "index out of range")
+	assert.Empty(t, root.TakeTemplateErrors())
+}
+
+func Test_ThemedTitle(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	testConfig.Theme = &terminal.Theme{
+		Level:       terminal.ColorLevelTrueColor,
+		FailedTitle: terminal.Style{Foreground: terminal.RGBColor(224, 108, 117), Bold: true},
+		Guides:      terminal.Style{Dim: true},
+	}
+	testConfig.Layout = config.LayoutTree
+	root := NewEchelonNode("root", testConfig)
+	parent := root.StartNewChild("parent")
+	parent.StartNewChild("child").CompleteWithColor(testConfig.FailureStatus, testConfig.Colors.FailureColor)
+	lines := root.Snapshot().FindChild("parent").Render(0)
+	assert.True(t, strings.HasPrefix(lines[1], "\033[2m└─\033[0m "), lines[1])
+	assert.Contains(t, lines[1], "\033[1;38;2;224;108;117mchild\033[0m")
+}
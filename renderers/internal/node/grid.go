@@ -0,0 +1,113 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/terminal"
+)
+
+// defaultGridWidth is used to wrap the grid when the terminal width is unknown.
+const defaultGridWidth = 80
+
+// usesGrid returns true if the siblings are shown as a grid of cells instead of a line per sibling.
+func usesGrid(snapshots []*Snapshot) bool {
+	if len(snapshots) == 0 {
+		return false
+	}
+	threshold := snapshots[0].config.GridThreshold
+	return threshold > 0 && len(snapshots) > threshold
+}
+
+// gridLines returns rows of cells with the status of each snapshot wrapped to the width followed by the legend.
+func gridLines(snapshots []*Snapshot, width int) []string {
+	if width <= 0 {
+		width = defaultGridWidth
+	}
+	cells := make([]string, 0, len(snapshots))
+	cellWidth := 1
+	for _, snapshot := range snapshots {
+		prefix := snapshot.statusPrefix()
+		if prefixWidth := terminal.StringWidth(prefix); prefixWidth > cellWidth {
+			cellWidth = prefixWidth
+		}
+		cells = append(cells, prefix)
+	}
+	cellsPerRow := (width + 1) / (cellWidth + 1)
+	if cellsPerRow < 1 {
+		cellsPerRow = 1
+	}
+	var result []string
+	var row strings.Builder
+	for i, cell := range cells {
+		if i%cellsPerRow != 0 {
+			row.WriteString(" ")
+		}
+		row.WriteString(snapshots[i].paint(cell))
+		row.WriteString(strings.Repeat(" ", cellWidth-terminal.StringWidth(cell)))
+		if i%cellsPerRow == cellsPerRow-1 || i == len(cells)-1 {
+			result = append(result, strings.TrimRight(row.String(), " "))
+			row.Reset()
+		}
+	}
+	return append(result, truncate(gridLegend(snapshots), width))
+}
+
+// gridLegend explains the cells and counts the snapshots by their status.
+func gridLegend(snapshots []*Snapshot) string {
+	counts := countStates(snapshots)
+	cfg := snapshots[0].config
+	var entries []string
+	entry := func(count int, status string, state config.ScopeState, label string) {
+		if count > 0 {
+			entries = append(entries, fmt.Sprintf("%s %s", status,
+				cfg.PaintTitle(state, fmt.Sprintf("%d %s", count, label))))
+		}
+	}
+	entry(counts.Passed, cfg.SuccessStatus, config.StateSucceeded, "succeeded")
+	entry(counts.Failed, cfg.FailureStatus, config.StateFailed, "failed")
+	entry(counts.Skipped, cfg.SkippedStatus, config.StateSkipped, "skipped")
+	entry(counts.Running, cfg.CurrentProgressIndicatorFrame(), config.StateRunning, "running")
+	entry(counts.Pending, pendingStatus, config.StatePending, "pending")
+	return strings.Join(entries, "  ")
+}
+
+// failedSnapshots returns the snapshots that are expanded below the grid.
+func failedSnapshots(snapshots []*Snapshot) []*Snapshot {
+	var result []*Snapshot
+	for _, snapshot := range snapshots {
+		if snapshot.hasFailed() {
+			result = append(result, snapshot)
+		}
+	}
+	return result
+}
+
+func (snapshot *Snapshot) hasFailed() bool {
+	return snapshot.HasCompleted() && snapshot.status == snapshot.config.FailureStatus
+}
+
+// renderGrid returns the grid prefixed with the indent and the failed snapshots below it.
+func renderGrid(
+	snapshots []*Snapshot,
+	width int,
+	columns ColumnWidths,
+	indent string,
+	guides *config.TreeGuides,
+) ([]string, bool) {
+	var result []string
+	for _, line := range gridLines(snapshots, width) {
+		result = append(result, indent+line)
+	}
+	failed := failedSnapshots(snapshots)
+	for i, snapshot := range failed {
+		lines, _ := snapshot.render(width, columns)
+		result = appendNested(result, lines, i == len(failed)-1, indent, guides)
+	}
+	isStatic := true
+	for _, snapshot := range snapshots {
+		isStatic = isStatic && !snapshot.HasRunningNodes()
+	}
+	return result, isStatic
+}
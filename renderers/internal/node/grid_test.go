@@ -0,0 +1,68 @@
+//nolint:testpackage
+package node
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGrid() *EchelonNode {
+	testConfig := newTestConfig()
+	testConfig.Colors = terminal.NoColorSchema()
+	testConfig.GridThreshold = 5
+	root := NewEchelonNode("root", testConfig)
+	parent := root.StartNewChild("parent")
+	for i := 0; i < 4; i++ {
+		parent.StartNewChild(fmt.Sprintf("succeeded %d", i)).CompleteWithColor(testConfig.SuccessStatus, -1)
+	}
+	failed := parent.StartNewChild("failed")
+	failed.AppendDescription("error")
+	failed.CompleteWithColor(testConfig.FailureStatus, -1)
+	parent.StartNewChild("skipped").CompleteWithColor(testConfig.SkippedStatus, -1)
+	parent.StartNewChild("running")
+	return root
+}
+
+func Test_Grid(t *testing.T) {
+	t.Parallel()
+	root := newTestGrid()
+	lines := root.Snapshot().FindChild("parent").Render(0)
+	assert.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[1], "  + + + + - ! "), lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "  + 4 succeeded  - 1 failed  ! 1 skipped  "), lines[2])
+	assert.True(t, strings.HasSuffix(lines[2], " 1 running"), lines[2])
+	assert.True(t, strings.HasPrefix(lines[3], "  - failed"), lines[3])
+	assert.Equal(t, "    error", lines[4])
+}
+
+func Test_Grid_WrapsToWidth(t *testing.T) {
+	t.Parallel()
+	root := newTestGrid()
+	lines := root.Snapshot().FindChild("parent").Render(12)
+	assert.Equal(t, "  + + + + -", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "  ! "), lines[2])
+	assert.Len(t, lines[2], 5)
+}
+
+func Test_Grid_BelowThreshold(t *testing.T) {
+	t.Parallel()
+	root := newTestGrid()
+	parent := root.Snapshot().FindChild("parent")
+	parent.config.GridThreshold = 7
+	assert.Len(t, parent.Render(0), 9)
+}
+
+func Test_RenderWithin_Grid(t *testing.T) {
+	t.Parallel()
+	root := newTestGrid()
+	parent := root.Snapshot().FindChild("parent")
+	lines := RenderWithin(parent.GetChildren(), 0, ColumnWidths{}, 100)
+	assert.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "+ + + + - ! "), lines[0])
+	assert.True(t, strings.HasPrefix(lines[2], "- failed"), lines[2])
+	assert.Equal(t, "  error", lines[3])
+}
@@ -0,0 +1,171 @@
+package node
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/cirruslabs/echelon/renderers/config"
+)
+
+// groupChildren replaces siblings having the same group key with an aggregated snapshot placed where the first
+// of them is. Keys with a single sibling are left alone.
+func groupChildren(children []*Snapshot, path []string, cfg *config.InteractiveRendererConfig) []*Snapshot {
+	keys := make([]string, len(children))
+	members := make(map[string][]*Snapshot)
+	for i, child := range children {
+		scopes := make([]string, 0, len(path)+1)
+		scopes = append(append(scopes, path...), child.title)
+		keys[i] = cfg.GroupKey(scopes)
+		if keys[i] != "" {
+			members[keys[i]] = append(members[keys[i]], child)
+		}
+	}
+	result := make([]*Snapshot, 0, len(children))
+	added := make(map[string]bool)
+	for i, child := range children {
+		key := keys[i]
+		switch {
+		case key == "" || len(members[key]) < 2:
+			result = append(result, child)
+		case !added[key]:
+			added[key] = true
+			result = append(result, newGroupSnapshot(key, members[key], cfg))
+		}
+	}
+	return result
+}
+
+// groupIDBit distinguishes IDs of aggregated rows from IDs of nodes.
+const groupIDBit = 1 << 63
+
+// groupID identifies the aggregated row by all of its members, so the row gets a new ID once it gains a member.
+func groupID(members []*Snapshot) uint64 {
+	hash := fnv.New64a()
+	var buffer [8]byte
+	for _, member := range members {
+		binary.LittleEndian.PutUint64(buffer[:], member.id)
+		_, _ = hash.Write(buffer[:])
+	}
+	return hash.Sum64() | groupIDBit
+}
+
+// newGroupSnapshot aggregates the members into a single row titled with the key and the counts of the members.
+// The row is running until all of the members complete and fails if any of them fails.
+func newGroupSnapshot(key string, members []*Snapshot, cfg *config.InteractiveRendererConfig) *Snapshot {
+	counts := countStates(members)
+	result := &Snapshot{
+		id:                      groupID(members),
+		groupKey:                key,
+		status:                  pendingStatus,
+		title:                   fmt.Sprintf("%s [%s]", key, counts),
+		titleColor:              cfg.ColorSchema().NeutralColor,
+		descriptionBuffer:       newDescriptionBuffer(0, false, ""),
+		visibleDescriptionLines: cfg.VisibleDescriptionLines,
+		config:                  cfg,
+		members:                 members,
+		templateErrors:          members[0].templateErrors,
+		failedWithin:            anyFailedWithin(members),
+	}
+	if memberPath := members[0].path; len(memberPath) > 0 {
+		result.path = append(append([]string(nil), memberPath[:len(memberPath)-1]...), key)
+	}
+	completed := true
+	for _, member := range members {
+		// members only grow their versions, so the sum changes every time any of them does
+		result.version += member.version
+		if !member.startTime.IsZero() && (result.startTime.IsZero() || member.startTime.Before(result.startTime)) {
+			result.startTime = member.startTime
+		}
+		if !member.HasCompleted() {
+			completed = false
+		} else if member.endTime.After(result.endTime) {
+			result.endTime = member.endTime
+		}
+	}
+	if !completed {
+		result.endTime = time.Time{}
+	}
+	switch {
+	case counts.Failed > 0:
+		result.titleColor = cfg.ColorSchema().FailureColor
+		if completed {
+			result.status = cfg.FailureStatus
+		}
+		if cfg.ExpandFailedGroups {
+			result.children = members
+		}
+	case completed && counts.Skipped == counts.Total():
+		result.status = cfg.SkippedStatus
+	case completed:
+		result.status = cfg.SuccessStatus
+		result.titleColor = cfg.ColorSchema().SuccessColor
+	}
+	return result
+}
+
+// MemberIDs returns IDs of the siblings aggregated into the row or nil if it's not a group.
+func (snapshot *Snapshot) MemberIDs() []uint64 {
+	if snapshot.members == nil {
+		return nil
+	}
+	result := make([]uint64, 0, len(snapshot.members))
+	for _, member := range snapshot.members {
+		result = append(result, member.id)
+	}
+	return result
+}
+
+// WithoutMembers returns the row aggregating only the members that aren't excluded, the only remaining member
+// itself or nil if all of them are excluded. Snapshots that aren't groups are returned as they are.
+func (snapshot *Snapshot) WithoutMembers(excluded func(id uint64) bool) *Snapshot {
+	if snapshot.members == nil {
+		return snapshot
+	}
+	remaining := make([]*Snapshot, 0, len(snapshot.members))
+	for _, member := range snapshot.members {
+		if !excluded(member.id) {
+			remaining = append(remaining, member)
+		}
+	}
+	switch len(remaining) {
+	case 0:
+		return nil
+	case 1:
+		return remaining[0]
+	case len(snapshot.members):
+		return snapshot
+	default:
+		return newGroupSnapshot(snapshot.groupKey, remaining, snapshot.config)
+	}
+}
+
+// countStates counts the snapshots by their state.
+func countStates(snapshots []*Snapshot) config.GroupCounts {
+	var result config.GroupCounts
+	for _, snapshot := range snapshots {
+		switch {
+		case snapshot.hasFailed():
+			result.Failed++
+		case snapshot.wasSkipped():
+			result.Skipped++
+		case snapshot.HasCompleted():
+			result.Passed++
+		case snapshot.IsRunning():
+			result.Running++
+		default:
+			result.Pending++
+		}
+	}
+	return result
+}
+
+// path returns the titles of the node and its ancestors except the root.
+func (node *EchelonNode) path() []string {
+	var result []string
+	for n := node; n != nil && n.parent != nil; n = n.parent {
+		result = append([]string{n.title}, result...)
+	}
+	return result
+}
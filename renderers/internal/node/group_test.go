@@ -0,0 +1,72 @@
+//nolint:testpackage
+package node
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestMatrix returns the root and the last member of the matrix which is still running.
+func newTestMatrix(expandFailed bool) (*EchelonNode, *EchelonNode) {
+	testConfig := newTestConfig()
+	testConfig.Colors = terminal.NoColorSchema()
+	testConfig.GroupKey = config.GroupMatrix()
+	testConfig.ExpandFailedGroups = expandFailed
+	root := NewEchelonNode("root", testConfig)
+	root.StartNewChild("build").CompleteWithColor(testConfig.SuccessStatus, -1)
+	for _, title := range []string{"test (go1.21, linux)", "test (go1.22, linux)", "test (go1.21, darwin)"} {
+		root.StartNewChild(title).CompleteWithColor(testConfig.SuccessStatus, -1)
+	}
+	running := root.StartNewChild("test (go1.22, darwin)")
+	root.StartNewChild("lint")
+	return root, running
+}
+
+func Test_Group_AggregatesSiblings(t *testing.T) {
+	t.Parallel()
+	root, _ := newTestMatrix(false)
+	children := root.Snapshot().GetChildren()
+	assert.Len(t, children, 3)
+	assert.Equal(t, "build", children[0].Title())
+	assert.Equal(t, "test [3/4 passed, 1 running]", children[1].Title())
+	assert.True(t, children[1].IsRunning())
+	assert.Equal(t, "lint", children[2].Title())
+	assert.NotNil(t, root.Snapshot().FindChild("test (go1.22, darwin)"))
+}
+
+func Test_Group_Completes(t *testing.T) {
+	t.Parallel()
+	root, running := newTestMatrix(false)
+	testConfig := running.config
+	running.CompleteWithColor(testConfig.SuccessStatus, -1)
+	group := root.Snapshot().GetChildren()[1]
+	assert.Equal(t, "test [4/4 passed]", group.Title())
+	assert.Equal(t, testConfig.SuccessStatus, group.Status())
+	assert.True(t, strings.HasPrefix(group.Render(0)[0], "+ test [4/4 passed] "))
+}
+
+func Test_Group_ExpandsFailed(t *testing.T) {
+	t.Parallel()
+	root, failed := newTestMatrix(true)
+	testConfig := failed.config
+	failed.AppendDescription("error")
+	failed.CompleteWithColor(testConfig.FailureStatus, -1)
+	lines := root.Snapshot().GetChildren()[1].Render(0)
+	assert.Len(t, lines, 6)
+	assert.True(t, strings.HasPrefix(lines[0], "- test [3/4 passed, 1 failed]"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[4], "  - test (go1.22, darwin)"), lines[4])
+	assert.Equal(t, "    error", lines[5])
+}
+
+func Test_GroupByPattern(t *testing.T) {
+	t.Parallel()
+	key := config.GroupByPattern(regexp.MustCompile(`^shard \d+`))
+	assert.Equal(t, "shard 1", key([]string{"parent", "shard 1 of 3"}))
+	assert.Equal(t, "", key([]string{"parent", "build"}))
+	assert.Equal(t, "test", config.GroupMatrix()([]string{"test (go1.21, linux)"}))
+}
@@ -0,0 +1,451 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/cirruslabs/echelon/utils"
+)
+
+// Snapshot is an immutable state of an EchelonNode and its children at some point in time.
+// It's safe to read and render snapshots from any goroutine.
+type Snapshot struct {
+	id                      uint64
+	version                 uint64
+	status                  string
+	title                   string
+	titleColor              int
+	description             []string // only the lines that can be visible
+	descriptionLength       int
+	descriptionOmitted      bool // some of the lines of output aren't visible
+	descriptionBuffer       *descriptionBuffer
+	visibleDescriptionLines int
+	config                  *config.InteractiveRendererConfig
+	startTime               time.Time
+	endTime                 time.Time
+	warnings                int
+	errors                  int
+	badges                  []badge
+	children                []*Snapshot
+	members                 []*Snapshot // aggregated siblings, children are the same if the group is expanded
+	groupKey                string      // only for groups
+	path                    []string    // only for title templates
+	templateErrors          *templateErrors
+	failedWithin            bool // the snapshot or any of its descendants failed
+
+	cacheLock sync.Mutex
+	cache     renderCache
+}
+
+// renderCache keeps rendered lines of a snapshot so they don't have to be rebuilt on every frame.
+type renderCache struct {
+	valid    bool
+	width    int
+	columns  ColumnWidths
+	indent   string
+	hasTail  bool
+	tail     []string // indented lines below the title
+	hasLines bool
+	lines    []string // all lines including the title, only for snapshots that aren't running
+}
+
+// ID identifies the node the snapshot was taken of, it's the same for all snapshots of the node.
+func (snapshot *Snapshot) ID() uint64 {
+	return snapshot.id
+}
+
+// Version is incremented every time the node or any of its descendants changes.
+func (snapshot *Snapshot) Version() uint64 {
+	return snapshot.version
+}
+
+func (snapshot *Snapshot) Title() string {
+	return snapshot.title
+}
+
+// Status is the status symbol the node was completed with.
+func (snapshot *Snapshot) Status() string {
+	return snapshot.status
+}
+
+// VisibleDescription returns the last lines of the output that are rendered below the title.
+func (snapshot *Snapshot) VisibleDescription() []string {
+	return snapshot.description
+}
+
+// RenderTitle returns just the title line of the node fitting the given terminal width.
+func (snapshot *Snapshot) RenderTitle(width int) string {
+	return snapshot.renderTitle(snapshot.statusPrefix(), width, ColumnWidths{})
+}
+
+func (snapshot *Snapshot) GetChildren() []*Snapshot {
+	return snapshot.children
+}
+
+func (snapshot *Snapshot) ExecutionDuration() time.Duration {
+	return executionDuration(snapshot.startTime, snapshot.endTime)
+}
+
+func (snapshot *Snapshot) HasStarted() bool {
+	return !snapshot.startTime.IsZero()
+}
+
+func (snapshot *Snapshot) HasCompleted() bool {
+	return !snapshot.endTime.IsZero()
+}
+
+func (snapshot *Snapshot) IsRunning() bool {
+	return !snapshot.startTime.IsZero() && snapshot.endTime.IsZero()
+}
+
+// WriteFullDescription writes the whole output of the node at the moment of the snapshot
+// including lines spilled to disk.
+func (snapshot *Snapshot) WriteFullDescription(w io.Writer) error {
+	return snapshot.descriptionBuffer.writeTo(w, snapshot.descriptionLength, true)
+}
+
+// WriteFullDescriptionWithoutVerbose is like WriteFullDescription but leaves out output appended
+// via AppendVerboseDescription.
+func (snapshot *Snapshot) WriteFullDescriptionWithoutVerbose(w io.Writer) error {
+	return snapshot.descriptionBuffer.writeTo(w, snapshot.descriptionLength, false)
+}
+
+// VisibleDescriptionWithoutVerbose is like VisibleDescription but leaves out output appended
+// via AppendVerboseDescription, so there might be more lines that are visible otherwise.
+func (snapshot *Snapshot) VisibleDescriptionWithoutVerbose() []string {
+	count := snapshot.visibleDescriptionLines
+	if !snapshot.HasCompleted() || count < 0 {
+		return snapshot.descriptionBuffer.tailWithoutVerbose(count, snapshot.descriptionLength)
+	}
+	result := snapshot.descriptionBuffer.tailWithoutVerbose(count+1, snapshot.descriptionLength)
+	if len(result) > 0 && result[len(result)-1] == "" {
+		result = result[:len(result)-1]
+	} else if len(result) > count {
+		result = result[1:]
+	}
+	return result
+}
+
+// SameDescription reports if both snapshots have the same output, so the full output read from one of them
+// is still valid for the other one.
+func (snapshot *Snapshot) SameDescription(other *Snapshot) bool {
+	return other != nil && snapshot.descriptionBuffer == other.descriptionBuffer &&
+		snapshot.descriptionLength == other.descriptionLength
+}
+
+// FindChild returns a descendant snapshot by titles of the nodes on the path to it.
+func (snapshot *Snapshot) FindChild(titles ...string) *Snapshot {
+	result := snapshot
+	for _, title := range titles {
+		found := result.findChild(title)
+		if found == nil {
+			return nil
+		}
+		result = found
+	}
+	return result
+}
+
+// ReleaseOutput removes spilled output of the node and all of its descendants.
+// Lines that don't fit in memory are dropped afterwards.
+func (snapshot *Snapshot) ReleaseOutput() {
+	snapshot.descriptionBuffer.close()
+	children := snapshot.children
+	if snapshot.members != nil {
+		children = snapshot.members
+	}
+	for _, child := range children {
+		child.ReleaseOutput()
+	}
+}
+
+// findChild returns the last child with the title looking into aggregated rows too.
+func (snapshot *Snapshot) findChild(title string) *Snapshot {
+	for i := len(snapshot.children) - 1; i >= 0; i-- {
+		child := snapshot.children[i]
+		if child.title == title {
+			return child
+		}
+		for j := len(child.members) - 1; j >= 0; j-- {
+			if child.members[j].title == title {
+				return child.members[j]
+			}
+		}
+	}
+	return nil
+}
+
+// HasRunningNodes returns true if the node itself or any of its descendants is running.
+func (snapshot *Snapshot) HasRunningNodes() bool {
+	if snapshot.IsRunning() {
+		return true
+	}
+	for _, child := range snapshot.children {
+		if child.HasRunningNodes() {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns lines of the node and all of its children fitting the given terminal width.
+// Non-positive width means that the width is unknown.
+func (snapshot *Snapshot) Render(width int) []string {
+	lines, _ := snapshot.render(width, ColumnWidths{})
+	return lines
+}
+
+// RenderAligned is like Render but in the column layout makes the columns at least as wide as the given ones,
+// usually ChildColumnWidths of the parent, so they line up with the siblings.
+func (snapshot *Snapshot) RenderAligned(width int, columns ColumnWidths) []string {
+	lines, _ := snapshot.render(width, columns)
+	return lines
+}
+
+// render also reports if the lines are static, i.e. won't change while time goes by.
+func (snapshot *Snapshot) render(width int, columns ColumnWidths) ([]string, bool) {
+	snapshot.cacheLock.Lock()
+	defer snapshot.cacheLock.Unlock()
+	cache := &snapshot.cache
+	if !cache.valid || cache.width != width || cache.columns != columns {
+		*cache = renderCache{valid: true, width: width, columns: columns}
+	}
+	if cache.hasLines {
+		return cache.lines, true
+	}
+	prefix := snapshot.statusPrefix()
+	title := snapshot.renderTitle(prefix, width, columns)
+	indent := snapshot.indent(prefix)
+	tail := cache.tail
+	tailIsStatic := cache.hasTail && cache.indent == indent
+	if !tailIsStatic {
+		tail, tailIsStatic = snapshot.renderTail(width, indent)
+		cache.indent = indent
+		cache.hasTail = tailIsStatic
+		cache.tail = tail
+	}
+	result := make([]string, 0, len(tail)+1)
+	result = append(result, title)
+	result = append(result, tail...)
+	isStatic := tailIsStatic && !snapshot.IsRunning()
+	if isStatic {
+		cache.hasLines = true
+		cache.lines = result
+	}
+	return result, isStatic
+}
+
+// indent returns the prefix of the lines of output below the title.
+func (snapshot *Snapshot) indent(statusPrefix string) string {
+	if guides := snapshot.config.Guides(); guides != nil {
+		return guides.Blank
+	}
+	if snapshot.config.IndentWidth > 0 {
+		return strings.Repeat(" ", snapshot.config.IndentWidth)
+	}
+	// align children and description with the title text no matter how wide the status is
+	return strings.Repeat(" ", terminal.StringWidth(statusPrefix)+1)
+}
+
+// tailWidth returns the width available for the lines below the title.
+func tailWidth(width int, indent string) int {
+	if width <= 0 {
+		return width
+	}
+	result := width - terminal.StringWidth(indent)
+	if result < 1 {
+		return 1
+	}
+	return result
+}
+
+func (snapshot *Snapshot) renderTail(width int, indent string) ([]string, bool) {
+	tailWidth := tailWidth(width, indent)
+	tail, isStatic := snapshot.renderChildren(tailWidth, indent)
+	if snapshot.descriptionOmitted {
+		tail = append(tail, indent+"...")
+	}
+	for _, descriptionLine := range snapshot.description {
+		tail = append(tail, indent+truncate(descriptionLine, tailWidth))
+	}
+	return tail, isStatic
+}
+
+// truncate cuts the line to the width unless it's unknown.
+func truncate(line string, width int) string {
+	if width <= 0 {
+		return line
+	}
+	return terminal.Truncate(line, width, terminal.Ellipsis)
+}
+
+// renderChildren returns lines of the children prefixed with the indent or tree guides.
+func (snapshot *Snapshot) renderChildren(width int, indent string) ([]string, bool) {
+	if usesGrid(snapshot.children) {
+		return renderGrid(snapshot.children, width, snapshot.ChildColumnWidths(), indent, snapshot.config.Guides())
+	}
+	var result []string
+	isStatic := true
+	guides := snapshot.config.Guides()
+	columns := snapshot.ChildColumnWidths()
+	children := sortSiblings(snapshot.children)
+	for i, child := range children {
+		lines, isChildStatic := child.render(width, columns)
+		result = appendNested(result, lines, i == len(children)-1, indent, guides)
+		isStatic = isStatic && isChildStatic
+	}
+	return result, isStatic
+}
+
+// appendNested appends lines of a child prefixed with the indent or, in tree layouts, with the guides
+// depending on whether the child is the last one.
+func appendNested(result []string, lines []string, last bool, indent string, guides *config.TreeGuides) []string {
+	first, rest := indent, indent
+	if guides != nil && !last {
+		first, rest = guides.Branch, guides.Vertical
+	} else if guides != nil {
+		first, rest = guides.Last, guides.Blank
+	}
+	for i, line := range lines {
+		if i == 0 {
+			result = append(result, first+line)
+		} else {
+			result = append(result, rest+line)
+		}
+	}
+	return result
+}
+
+func (snapshot *Snapshot) statusPrefix() string {
+	if snapshot.IsRunning() {
+		return snapshot.config.CurrentProgressIndicatorFrame()
+	}
+	return snapshot.status
+}
+
+// renderTitle returns the title line in the configured layout fitting the width.
+func (snapshot *Snapshot) renderTitle(prefix string, width int, columns ColumnWidths) string {
+	if snapshot.config.ColumnLayout && width > 0 {
+		return snapshot.columnTitle(prefix, width, columns)
+	}
+	return truncate(snapshot.fancyTitle(prefix), width)
+}
+
+func (snapshot *Snapshot) fancyTitle(prefix string) string {
+	if snapshot.config.TitleTemplate != nil {
+		title, err := snapshot.templateTitle(prefix)
+		if err == nil {
+			return title
+		}
+		snapshot.templateErrors.report(err)
+	}
+	duration := utils.FormatDuration(snapshot.ExecutionDuration(), len(snapshot.children) == 0)
+	return fmt.Sprintf("%s %s %s", snapshot.paintPrefix(prefix), snapshot.coloredTitle(),
+		snapshot.config.PaintDuration(duration))
+}
+
+// templateTitle executes the title template. Line breaks are replaced with spaces to keep the title on a single line.
+func (snapshot *Snapshot) templateTitle(prefix string) (string, error) {
+	completed := 0
+	for _, child := range snapshot.children {
+		if child.HasCompleted() {
+			completed++
+		}
+	}
+	var fields map[string]string
+	if len(snapshot.badges) > 0 {
+		fields = make(map[string]string, len(snapshot.badges))
+		for _, badge := range snapshot.badges {
+			fields[badge.name] = badge.text
+		}
+	}
+	var result strings.Builder
+	err := snapshot.config.TitleTemplate.Execute(&result, config.TemplateData{
+		Name:        snapshot.title,
+		ColoredName: snapshot.coloredTitle(),
+		Path:        snapshot.path,
+		Depth:       len(snapshot.path),
+		Status:      prefix,
+		StartTime:   snapshot.startTime,
+		Duration:    snapshot.ExecutionDuration(),
+		Completed:   completed,
+		Total:       len(snapshot.children),
+		Fields:      fields,
+	})
+	return strings.ReplaceAll(result.String(), "\n", " "), err
+}
+
+// templateErrors collects errors of executing the title template, so renderers can report them instead of
+// titles silently falling back to the default ones.
+type templateErrors struct {
+	lock     sync.Mutex
+	reported map[string]bool
+	pending  []error
+}
+
+func (errs *templateErrors) report(err error) {
+	if errs == nil {
+		return
+	}
+	errs.lock.Lock()
+	defer errs.lock.Unlock()
+	if errs.reported[err.Error()] {
+		return
+	}
+	if errs.reported == nil {
+		errs.reported = make(map[string]bool)
+	}
+	errs.reported[err.Error()] = true
+	errs.pending = append(errs.pending, err)
+}
+
+func (errs *templateErrors) take() []error {
+	errs.lock.Lock()
+	defer errs.lock.Unlock()
+	result := errs.pending
+	errs.pending = nil
+	return result
+}
+
+func (snapshot *Snapshot) coloredTitle() string {
+	return snapshot.paint(snapshot.title)
+}
+
+// paint styles the text like the title with the theme or the color of the title if there's no theme.
+func (snapshot *Snapshot) paint(text string) string {
+	if snapshot.config.Theme != nil {
+		return snapshot.config.PaintTitle(snapshot.state(), text)
+	}
+	if snapshot.titleColor >= 0 {
+		return terminal.GetColoredText(snapshot.titleColor, text)
+	}
+	return text
+}
+
+func (snapshot *Snapshot) state() config.ScopeState {
+	switch {
+	case snapshot.hasFailed():
+		return config.StateFailed
+	case snapshot.wasSkipped():
+		return config.StateSkipped
+	case snapshot.HasCompleted():
+		return config.StateSucceeded
+	case snapshot.IsRunning():
+		return config.StateRunning
+	default:
+		return config.StatePending
+	}
+}
+
+// paintPrefix styles the progress indicator of running scopes.
+func (snapshot *Snapshot) paintPrefix(prefix string) string {
+	if snapshot.IsRunning() {
+		return snapshot.config.PaintSpinner(prefix)
+	}
+	return prefix
+}
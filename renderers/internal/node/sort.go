@@ -0,0 +1,56 @@
+package node
+
+import (
+	"sort"
+
+	"github.com/cirruslabs/echelon/renderers/config"
+)
+
+// sortSiblings returns the snapshots in the configured order. The sort is stable, so siblings in the same state
+// keep the order they were started in.
+func sortSiblings(snapshots []*Snapshot) []*Snapshot {
+	if len(snapshots) < 2 {
+		return snapshots
+	}
+	cfg := snapshots[0].config
+	if cfg.SortOrder == config.SortByInsertion && !cfg.PinFailedToBottom {
+		return snapshots
+	}
+	result := append([]*Snapshot(nil), snapshots...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return drawnBefore(result[i], result[j], cfg)
+	})
+	return result
+}
+
+// anyFailedWithin reports if any of the snapshots or their descendants failed.
+func anyFailedWithin(snapshots []*Snapshot) bool {
+	for _, snapshot := range snapshots {
+		if snapshot.failedWithin {
+			return true
+		}
+	}
+	return false
+}
+
+func drawnBefore(a *Snapshot, b *Snapshot, cfg *config.InteractiveRendererConfig) bool {
+	// siblings with failures inside are drawn last on every level, so failed scopes end up at the bottom of the frame
+	if cfg.PinFailedToBottom && a.failedWithin != b.failedWithin {
+		return b.failedWithin
+	}
+	aFailed, bFailed := a.hasFailed(), b.hasFailed()
+	aRunning, bRunning := a.IsRunning(), b.IsRunning()
+	switch cfg.SortOrder {
+	case config.SortRunningFirst:
+		return aRunning && !bRunning
+	case config.SortFailedFirst:
+		return aFailed && !bFailed
+	case config.SortLongestRunningFirst:
+		if aRunning != bRunning {
+			return aRunning
+		}
+		return aRunning && a.startTime.Before(b.startTime)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,80 @@
+//nolint:testpackage
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSiblings(order config.SortOrder, pinFailed bool) []*Snapshot {
+	testConfig := newTestConfig()
+	testConfig.SortOrder = order
+	testConfig.PinFailedToBottom = pinFailed
+	root := NewEchelonNode("root", testConfig)
+	root.StartNewChild("succeeded").CompleteWithColor(testConfig.SuccessStatus, -1)
+	newer := root.StartNewChild("newer")
+	root.StartNewChild("failed").CompleteWithColor(testConfig.FailureStatus, -1)
+	older := root.StartNewChild("older")
+	older.startTime = newer.startTime.Add(-time.Minute)
+	root.StartNewChild("skipped").CompleteWithColor(testConfig.SkippedStatus, -1)
+	return root.Snapshot().GetChildren()
+}
+
+func titles(snapshots []*Snapshot) []string {
+	result := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		result = append(result, snapshot.Title())
+	}
+	return result
+}
+
+func Test_SortSiblings(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		order     config.SortOrder
+		pinFailed bool
+		expected  []string
+	}{
+		{"insertion", config.SortByInsertion, false, []string{"succeeded", "newer", "failed", "older", "skipped"}},
+		{"running first", config.SortRunningFirst, false, []string{"newer", "older", "succeeded", "failed", "skipped"}},
+		{"failed first", config.SortFailedFirst, false, []string{"failed", "succeeded", "newer", "older", "skipped"}},
+		{"longest running", config.SortLongestRunningFirst, false,
+			[]string{"older", "newer", "succeeded", "failed", "skipped"}},
+		{"pinned", config.SortByInsertion, true, []string{"succeeded", "newer", "older", "skipped", "failed"}},
+		{"pinned running first", config.SortRunningFirst, true,
+			[]string{"newer", "older", "succeeded", "skipped", "failed"}},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			snapshots := newTestSiblings(testCase.order, testCase.pinFailed)
+			assert.Equal(t, testCase.expected, titles(sortSiblings(snapshots)))
+			assert.Contains(t, RenderWithin(snapshots, 0, ColumnWidths{}, 0)[0], testCase.expected[0])
+		})
+	}
+}
+
+func Test_SortSiblings_PinsFailedDescendants(t *testing.T) {
+	t.Parallel()
+	testConfig := newTestConfig()
+	testConfig.Colors = terminal.NoColorSchema()
+	testConfig.PinFailedToBottom = true
+	root := NewEchelonNode("root", testConfig)
+	build := root.StartNewChild("build")
+	build.StartNewChild("compile").CompleteWithColor(testConfig.FailureStatus, -1)
+	build.StartNewChild("link").CompleteWithColor(testConfig.SuccessStatus, -1)
+	root.StartNewChild("lint").CompleteWithColor(testConfig.SuccessStatus, -1)
+	root.StartNewChild("test")
+
+	children := root.Snapshot().GetChildren()
+	// build is still running but has a failed child, so it goes after its siblings like the child itself
+	assert.Equal(t, []string{"lint", "test", "build"}, titles(sortSiblings(children)))
+	lines := RenderWithin(children, 0, ColumnWidths{}, 0)
+	assert.Contains(t, lines[len(lines)-1], "compile")
+}
@@ -0,0 +1,711 @@
+package renderers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/terminal"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvironmentPrefix starts the names of environment variables with settings, e.g. ECHELON_VISIBLE_LINES.
+const EnvironmentPrefix = "ECHELON_"
+
+// RendererKind is the renderer chosen in the settings.
+type RendererKind int
+
+const (
+	// RendererAuto lets NewAuto detect the renderer.
+	RendererAuto RendererKind = iota
+	RendererInteractive
+	RendererFullScreen
+	RendererSimple
+)
+
+var rendererKindNames = []string{"auto", "interactive", "fullscreen", "simple"}
+
+func (kind RendererKind) String() string {
+	if int(kind) < len(rendererKindNames) {
+		return rendererKindNames[kind]
+	}
+	return strconv.Itoa(int(kind))
+}
+
+// SettingsFormat is the format of settings files.
+type SettingsFormat int
+
+const (
+	FormatJSON SettingsFormat = iota
+	FormatYAML
+	FormatTOML
+)
+
+// Settings let end users customize the output via files and environment variables without flags for every knob.
+// The theme, grouping and templates apply to both configs.
+type Settings struct {
+	Renderer    RendererKind
+	Interactive *config.InteractiveRendererConfig
+	Simple      *config.SimpleRendererConfig
+}
+
+// SettingError points at the setting that failed to load.
+type SettingError struct {
+	// Source is the path of the file or empty for environment variables and data.
+	Source string
+	// Key is the dotted path of the setting like "theme.failed_title" or the name of the environment variable.
+	Key string
+	Err error
+}
+
+func (err *SettingError) Error() string {
+	if err.Source == "" {
+		return fmt.Sprintf("%s: %v", err.Key, err.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", err.Source, err.Key, err.Err)
+}
+
+func (err *SettingError) Unwrap() error {
+	return err.Err
+}
+
+func NewDefaultSettings() *Settings {
+	return &Settings{
+		Interactive: config.NewDefaultRenderingConfig(),
+		Simple:      config.NewDefaultSimpleRendererConfig(),
+	}
+}
+
+// LoadSettings returns the default settings overridden by the file if the path is not empty and then by
+// the environment variables in the "NAME=value" form, usually os.Environ().
+func LoadSettings(path string, environ []string) (*Settings, error) {
+	result := NewDefaultSettings()
+	if path != "" {
+		if err := result.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := result.LoadEnvironment(environ); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadFile overrides the settings with a .json, .yaml, .yml or .toml file.
+func (settings *Settings) LoadFile(path string) error {
+	var format SettingsFormat
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = FormatJSON
+	case ".yaml", ".yml":
+		format = FormatYAML
+	case ".toml":
+		format = FormatTOML
+	default:
+		return fmt.Errorf("%s: unknown format, expected a .json, .yaml, .yml or .toml file", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := settings.Load(data, format); err != nil {
+		if settingErr, ok := err.(*SettingError); ok {
+			settingErr.Source = path
+			return settingErr
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Load overrides the settings with the document. Nested tables and dotted keys are equivalent, e.g.
+// "theme: {name: dark}" and "theme.name: dark".
+func (settings *Settings) Load(data []byte, format SettingsFormat) error {
+	document := make(map[string]interface{})
+	var err error
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&document)
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &document)
+	case FormatTOML:
+		_, err = toml.Decode(string(data), &document)
+	}
+	if err != nil {
+		return err
+	}
+	values := make(map[string]settingValue)
+	if err := flattenSettings(document, "", values); err != nil {
+		return err
+	}
+	return settings.apply(values)
+}
+
+// LoadEnvironment overrides the settings with the variables in the "NAME=value" form starting with
+// EnvironmentPrefix, the rest of the name is the key of the setting with dots replaced by underscores, e.g.
+// ECHELON_THEME_FAILED_TITLE. Lists are separated by commas. Other variables with the prefix are ignored since they
+// might belong to the application.
+func (settings *Settings) LoadEnvironment(environ []string) error {
+	keys := make(map[string]string)
+	for _, definition := range settingDefinitions {
+		keys[environmentName(definition.key)] = definition.key
+	}
+	for alias, key := range settingAliases {
+		keys[environmentName(alias)] = key
+	}
+	values := make(map[string]settingValue)
+	for _, variable := range environ {
+		name, value := variable, ""
+		if i := strings.IndexByte(variable, '='); i >= 0 {
+			name, value = variable[:i], variable[i+1:]
+		}
+		if !strings.HasPrefix(name, EnvironmentPrefix) {
+			continue
+		}
+		key, ok := keys[name]
+		if !ok {
+			continue
+		}
+		if previous, ok := values[key]; ok && previous.name == name {
+			// the last one wins like in shells
+			delete(values, key)
+		}
+		if err := addSetting(values, key, settingValue{name: name, value: value}); err != nil {
+			return err
+		}
+	}
+	return settings.apply(values)
+}
+
+func environmentName(key string) string {
+	return EnvironmentPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// settingValue is the value of a setting and the name it was spelled with.
+type settingValue struct {
+	name  string
+	value interface{}
+}
+
+// addSetting rejects values of settings that are already set, e.g. both "theme" and "theme.name".
+func addSetting(values map[string]settingValue, key string, value settingValue) error {
+	if previous, ok := values[key]; ok {
+		if previous.name == value.name {
+			return &SettingError{Key: value.name, Err: fmt.Errorf("the setting is set twice")}
+		}
+		return &SettingError{Key: value.name, Err: fmt.Errorf("the setting is already set as %s", previous.name)}
+	}
+	values[key] = value
+	return nil
+}
+
+func flattenSettings(document map[string]interface{}, prefix string, values map[string]settingValue) error {
+	// sorted, so that errors about duplicates are always the same
+	names := make([]string, 0, len(document))
+	for name := range document {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := document[name]
+		path := prefix + name
+		key := path
+		if alias, ok := settingAliases[path]; ok {
+			if _, isTable := value.(map[string]interface{}); !isTable {
+				key = alias
+			}
+		}
+		if findSetting(key) != nil {
+			if err := addSetting(values, key, settingValue{name: path, value: value}); err != nil {
+				return err
+			}
+			continue
+		}
+		table, isTable := value.(map[string]interface{})
+		if !isTable {
+			return &SettingError{Key: path, Err: fmt.Errorf("unknown setting")}
+		}
+		if err := flattenSettings(table, path+".", values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply applies the values in the order of the definitions, e.g. the base theme before its styles.
+func (settings *Settings) apply(values map[string]settingValue) error {
+	for _, definition := range settingDefinitions {
+		value, ok := values[definition.key]
+		if !ok {
+			continue
+		}
+		if err := definition.apply(settings, value.value); err != nil {
+			return &SettingError{Key: value.name, Err: err}
+		}
+	}
+	return nil
+}
+
+type settingDefinition struct {
+	key   string
+	apply func(settings *Settings, value interface{}) error
+}
+
+func findSetting(key string) *settingDefinition {
+	for i := range settingDefinitions {
+		if settingDefinitions[i].key == key {
+			return &settingDefinitions[i]
+		}
+	}
+	return nil
+}
+
+// settingAliases are shorter keys for scalar values of tables, e.g. "theme: dark".
+var settingAliases = map[string]string{
+	"theme":               "theme.name",
+	"retention.succeeded": "retention.succeeded.mode",
+	"retention.failed":    "retention.failed.mode",
+	"retention.skipped":   "retention.skipped.mode",
+}
+
+var themes = map[string]func() *terminal.Theme{
+	"dark":          terminal.DarkTheme,
+	"light":         terminal.LightTheme,
+	"high-contrast": terminal.HighContrastTheme,
+}
+
+// spinners are frames of progress indicators by their names.
+var spinners = map[string][]string{
+	"clock": {"🕐", "🕑", "🕒", "🕓", "🕔", "🕕", "🕖", "🕗", "🕘", "🕙", "🕚", "🕛"},
+	"dots":  {"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+	"line":  {"\\", "|", "/", "-"},
+	"arc":   {"◜", "◠", "◝", "◞", "◡", "◟"},
+}
+
+// isSpinnerName reports if the value is rather a misspelled name of a spinner than a single frame.
+func isSpinnerName(value string) bool {
+	if len(value) < 2 {
+		return false
+	}
+	for _, r := range value {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+var retentionModeNames = []string{"default", "children", "description", "title", "remove"}
+
+var settingDefinitions = []settingDefinition{
+	{"renderer", func(settings *Settings, value interface{}) error {
+		kind, err := enumSetting(value, rendererKindNames...)
+		if err == nil {
+			settings.Renderer = RendererKind(kind)
+		}
+		return err
+	}},
+	{"theme.name", func(settings *Settings, value interface{}) error {
+		name, err := stringSetting(value)
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "default":
+			settings.setTheme(nil, terminal.DefaultColorSchema())
+		case "none":
+			settings.setTheme(nil, terminal.NoColorSchema())
+		default:
+			theme, ok := themes[name]
+			if !ok {
+				return fmt.Errorf("unknown theme %q, expected default, none, dark, light or high-contrast", name)
+			}
+			settings.setTheme(theme(), settings.Interactive.Colors)
+		}
+		return nil
+	}},
+	{"theme.level", func(settings *Settings, value interface{}) error {
+		text, err := stringSetting(value)
+		if err != nil {
+			return err
+		}
+		level, err := terminal.ParseColorLevel(text)
+		if err == nil {
+			settings.theme().Level = level
+		}
+		return err
+	}},
+	themeStyleSetting("theme.pending_title", func(theme *terminal.Theme) *terminal.Style { return &theme.PendingTitle }),
+	themeStyleSetting("theme.running_title", func(theme *terminal.Theme) *terminal.Style { return &theme.RunningTitle }),
+	themeStyleSetting("theme.succeeded_title", func(theme *terminal.Theme) *terminal.Style {
+		return &theme.SucceededTitle
+	}),
+	themeStyleSetting("theme.failed_title", func(theme *terminal.Theme) *terminal.Style { return &theme.FailedTitle }),
+	themeStyleSetting("theme.skipped_title", func(theme *terminal.Theme) *terminal.Style { return &theme.SkippedTitle }),
+	themeStyleSetting("theme.duration", func(theme *terminal.Theme) *terminal.Style { return &theme.Duration }),
+	themeStyleSetting("theme.spinner", func(theme *terminal.Theme) *terminal.Style { return &theme.Spinner }),
+	themeStyleSetting("theme.error_message", func(theme *terminal.Theme) *terminal.Style { return &theme.ErrorMessage }),
+	themeStyleSetting("theme.warn_message", func(theme *terminal.Theme) *terminal.Style { return &theme.WarnMessage }),
+	themeStyleSetting("theme.info_message", func(theme *terminal.Theme) *terminal.Style { return &theme.InfoMessage }),
+	themeStyleSetting("theme.debug_message", func(theme *terminal.Theme) *terminal.Style { return &theme.DebugMessage }),
+	themeStyleSetting("theme.trace_message", func(theme *terminal.Theme) *terminal.Style { return &theme.TraceMessage }),
+	themeStyleSetting("theme.guides", func(theme *terminal.Theme) *terminal.Style { return &theme.Guides }),
+	{"spinner", func(settings *Settings, value interface{}) error {
+		if name, ok := value.(string); ok && !strings.Contains(name, ",") {
+			frames, ok := spinners[name]
+			switch {
+			case ok:
+				settings.Interactive.ProgressIndicatorFrames = frames
+			case isSpinnerName(name):
+				return fmt.Errorf("unknown spinner %q, expected one of %s or a list of frames",
+					name, strings.Join(sortedKeys(spinners), ", "))
+			default:
+				settings.Interactive.ProgressIndicatorFrames = []string{name}
+			}
+			return nil
+		}
+		frames, err := stringsSetting(value)
+		if err == nil && len(frames) == 0 {
+			err = fmt.Errorf("expected at least one frame")
+		}
+		if err != nil {
+			return err
+		}
+		settings.Interactive.ProgressIndicatorFrames = frames
+		return nil
+	}},
+	{"spinner_cycle", func(settings *Settings, value interface{}) error {
+		duration, err := durationSetting(value)
+		if err == nil && duration <= 0 {
+			err = fmt.Errorf("expected a positive duration")
+		}
+		if err == nil {
+			settings.Interactive.ProgressIndicatorCycleDuration = duration
+		}
+		return err
+	}},
+	stringFieldSetting("success_status", func(settings *Settings) *string { return &settings.Interactive.SuccessStatus }),
+	stringFieldSetting("failure_status", func(settings *Settings) *string { return &settings.Interactive.FailureStatus }),
+	stringFieldSetting("skipped_status", func(settings *Settings) *string { return &settings.Interactive.SkippedStatus }),
+	intFieldSetting("visible_lines", func(settings *Settings) *int {
+		return &settings.Interactive.VisibleDescriptionLines
+	}),
+	intFieldSetting("failed_lines", func(settings *Settings) *int {
+		return &settings.Interactive.DescriptionLinesWhenFailed
+	}),
+	intFieldSetting("skipped_lines", func(settings *Settings) *int {
+		return &settings.Interactive.DescriptionLinesWhenSkipped
+	}),
+	intFieldSetting("max_lines", func(settings *Settings) *int { return &settings.Interactive.MaxDescriptionLines }),
+	boolFieldSetting("spill_to_disk", func(settings *Settings) *bool {
+		return &settings.Interactive.SpillDescriptionToDisk
+	}),
+	boolFieldSetting("keep_spilled_output", func(settings *Settings) *bool {
+		return &settings.Interactive.KeepSpilledOutput
+	}),
+	stringFieldSetting("spill_directory", func(settings *Settings) *string {
+		return &settings.Interactive.SpillDirectory
+	}),
+	intFieldSetting("max_frame_rate", func(settings *Settings) *int { return &settings.Interactive.MaxFrameRate }),
+	boolFieldSetting("commit_finished", func(settings *Settings) *bool {
+		return &settings.Interactive.CommitFinishedScopes
+	}),
+	{"synchronized_output", func(settings *Settings, value interface{}) error {
+		mode, err := enumSetting(value, "auto", "enabled", "disabled")
+		if err == nil {
+			settings.Interactive.SynchronizedOutput = config.FeatureMode(mode)
+		}
+		return err
+	}},
+	boolFieldSetting("hide_cursor", func(settings *Settings) *bool { return &settings.Interactive.HideCursor }),
+	{"signals", func(settings *Settings, value interface{}) error {
+		handling, err := enumSetting(value, "exit", "restore", "ignore")
+		if err == nil {
+			settings.Interactive.TerminationSignals = config.SignalHandling(handling)
+		}
+		return err
+	}},
+	{"layout", func(settings *Settings, value interface{}) error {
+		layout, err := enumSetting(value, "indented", "tree", "ascii-tree")
+		if err == nil {
+			settings.Interactive.Layout = config.Layout(layout)
+		}
+		return err
+	}},
+	intFieldSetting("indent_width", func(settings *Settings) *int { return &settings.Interactive.IndentWidth }),
+	boolFieldSetting("columns", func(settings *Settings) *bool { return &settings.Interactive.ColumnLayout }),
+	boolFieldSetting("fit_to_screen", func(settings *Settings) *bool { return &settings.Interactive.FitToScreen }),
+	intFieldSetting("grid_threshold", func(settings *Settings) *int { return &settings.Interactive.GridThreshold }),
+	{"group", func(settings *Settings, value interface{}) error {
+		text, err := stringSetting(value)
+		if err != nil {
+			return err
+		}
+		var key config.GroupKeyFunc
+		switch text {
+		case "":
+		case "matrix":
+			key = config.GroupMatrix()
+		default:
+			pattern, err := regexp.Compile(text)
+			if err != nil {
+				return err
+			}
+			key = config.GroupByPattern(pattern)
+		}
+		settings.Interactive.GroupKey = key
+		settings.Simple.GroupKey = key
+		return nil
+	}},
+	boolFieldSetting("expand_failed_groups", func(settings *Settings) *bool {
+		return &settings.Interactive.ExpandFailedGroups
+	}),
+	{"sort", func(settings *Settings, value interface{}) error {
+		order, err := enumSetting(value, "insertion", "running-first", "failed-first", "longest-running-first")
+		if err == nil {
+			settings.Interactive.SortOrder = config.SortOrder(order)
+		}
+		return err
+	}},
+	boolFieldSetting("pin_failed", func(settings *Settings) *bool { return &settings.Interactive.PinFailedToBottom }),
+	retentionModeSetting("retention.succeeded.mode", func(settings *Settings) *echelon.RetentionPolicy {
+		return &settings.Interactive.RetentionWhenSucceeded
+	}),
+	retentionLinesSetting("retention.succeeded.lines", func(settings *Settings) *echelon.RetentionPolicy {
+		return &settings.Interactive.RetentionWhenSucceeded
+	}),
+	retentionModeSetting("retention.failed.mode", func(settings *Settings) *echelon.RetentionPolicy {
+		return &settings.Interactive.RetentionWhenFailed
+	}),
+	retentionLinesSetting("retention.failed.lines", func(settings *Settings) *echelon.RetentionPolicy {
+		return &settings.Interactive.RetentionWhenFailed
+	}),
+	retentionModeSetting("retention.skipped.mode", func(settings *Settings) *echelon.RetentionPolicy {
+		return &settings.Interactive.RetentionWhenSkipped
+	}),
+	retentionLinesSetting("retention.skipped.lines", func(settings *Settings) *echelon.RetentionPolicy {
+		return &settings.Interactive.RetentionWhenSkipped
+	}),
+	templateSetting("title_template", func(settings *Settings) **template.Template {
+		return &settings.Interactive.TitleTemplate
+	}),
+	templateSetting("started_template", func(settings *Settings) **template.Template {
+		return &settings.Simple.StartedTemplate
+	}),
+	templateSetting("succeeded_template", func(settings *Settings) **template.Template {
+		return &settings.Simple.SucceededTemplate
+	}),
+	templateSetting("failed_template", func(settings *Settings) **template.Template {
+		return &settings.Simple.FailedTemplate
+	}),
+	templateSetting("skipped_template", func(settings *Settings) **template.Template {
+		return &settings.Simple.SkippedTemplate
+	}),
+}
+
+func (settings *Settings) setTheme(theme *terminal.Theme, colors *terminal.ColorSchema) {
+	settings.Interactive.Theme, settings.Interactive.Colors = theme, colors
+	settings.Simple.Theme, settings.Simple.Colors = theme, colors
+}
+
+// theme returns the theme creating one with the current colors if there's none, so that styles can be set one by one
+// without losing the rest of them.
+func (settings *Settings) theme() *terminal.Theme {
+	if settings.Interactive.Theme == nil {
+		settings.setTheme(terminal.ColorSchemaTheme(settings.Interactive.Colors), settings.Interactive.Colors)
+	}
+	return settings.Interactive.Theme
+}
+
+func themeStyleSetting(key string, field func(theme *terminal.Theme) *terminal.Style) settingDefinition {
+	return settingDefinition{key, func(settings *Settings, value interface{}) error {
+		text, err := stringSetting(value)
+		if err != nil {
+			return err
+		}
+		style, err := terminal.ParseStyle(text)
+		if err != nil {
+			return err
+		}
+		*field(settings.theme()) = style
+		return nil
+	}}
+}
+
+func stringFieldSetting(key string, field func(settings *Settings) *string) settingDefinition {
+	return settingDefinition{key, func(settings *Settings, value interface{}) error {
+		text, err := stringSetting(value)
+		if err == nil {
+			*field(settings) = text
+		}
+		return err
+	}}
+}
+
+func intFieldSetting(key string, field func(settings *Settings) *int) settingDefinition {
+	return settingDefinition{key, func(settings *Settings, value interface{}) error {
+		number, err := intSetting(value)
+		if err == nil {
+			*field(settings) = number
+		}
+		return err
+	}}
+}
+
+func boolFieldSetting(key string, field func(settings *Settings) *bool) settingDefinition {
+	return settingDefinition{key, func(settings *Settings, value interface{}) error {
+		flag, err := boolSetting(value)
+		if err == nil {
+			*field(settings) = flag
+		}
+		return err
+	}}
+}
+
+func retentionModeSetting(key string, field func(settings *Settings) *echelon.RetentionPolicy) settingDefinition {
+	return settingDefinition{key, func(settings *Settings, value interface{}) error {
+		mode, err := enumSetting(value, retentionModeNames...)
+		if err == nil {
+			field(settings).Mode = echelon.RetentionMode(mode)
+		}
+		return err
+	}}
+}
+
+func retentionLinesSetting(key string, field func(settings *Settings) *echelon.RetentionPolicy) settingDefinition {
+	return settingDefinition{key, func(settings *Settings, value interface{}) error {
+		lines, err := intSetting(value)
+		if err == nil {
+			field(settings).Lines = lines
+		}
+		return err
+	}}
+}
+
+func templateSetting(key string, field func(settings *Settings) **template.Template) settingDefinition {
+	return settingDefinition{key, func(settings *Settings, value interface{}) error {
+		text, err := stringSetting(value)
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			*field(settings) = nil
+			return nil
+		}
+		tmpl, err := ParseTemplate(key, text)
+		if err == nil {
+			*field(settings) = tmpl
+		}
+		return err
+	}}
+}
+
+func stringSetting(value interface{}) (string, error) {
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	return "", fmt.Errorf("expected a string, got %v", value)
+}
+
+func intSetting(value interface{}) (int, error) {
+	switch number := value.(type) {
+	case int:
+		return number, nil
+	case int64:
+		return int(number), nil
+	case float64:
+		if number == float64(int(number)) {
+			return int(number), nil
+		}
+	case json.Number:
+		if result, err := strconv.Atoi(number.String()); err == nil {
+			return result, nil
+		}
+	case string:
+		if result, err := strconv.Atoi(strings.TrimSpace(number)); err == nil {
+			return result, nil
+		}
+		return 0, fmt.Errorf("expected an integer, got %q", number)
+	}
+	return 0, fmt.Errorf("expected an integer, got %v", value)
+}
+
+func boolSetting(value interface{}) (bool, error) {
+	switch flag := value.(type) {
+	case bool:
+		return flag, nil
+	case string:
+		if result, err := strconv.ParseBool(strings.TrimSpace(flag)); err == nil {
+			return result, nil
+		}
+		return false, fmt.Errorf("expected true or false, got %q", flag)
+	}
+	return false, fmt.Errorf("expected true or false, got %v", value)
+}
+
+func durationSetting(value interface{}) (time.Duration, error) {
+	text, err := stringSetting(value)
+	if err != nil {
+		return 0, fmt.Errorf("expected a duration like 1s or 500ms, got %v", value)
+	}
+	result, err := time.ParseDuration(strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf("expected a duration like 1s or 500ms, got %q", text)
+	}
+	return result, nil
+}
+
+// stringsSetting accepts lists of strings and strings separated by commas.
+func stringsSetting(value interface{}) ([]string, error) {
+	switch list := value.(type) {
+	case string:
+		return strings.Split(list, ","), nil
+	case []interface{}:
+		result := make([]string, 0, len(list))
+		for _, item := range list {
+			text, err := stringSetting(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, text)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("expected a list of strings, got %v", value)
+}
+
+// enumSetting returns the index of the name matching the value.
+func enumSetting(value interface{}, names ...string) (int, error) {
+	text, err := stringSetting(value)
+	if err != nil {
+		return 0, err
+	}
+	for i, name := range names {
+		if strings.EqualFold(strings.TrimSpace(text), name) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown value %q, expected one of %s", text, strings.Join(names, ", "))
+}
+
+func sortedKeys(m map[string][]string) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,197 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Settings_Formats(t *testing.T) {
+	t.Parallel()
+	documents := map[SettingsFormat]string{
+		FormatJSON: `{
+			"renderer": "simple",
+			"visible_lines": 10,
+			"spinner": ["a", "b"],
+			"theme": {"name": "dark", "level": "256", "failed_title": "bold white on red"},
+			"retention": {"failed": {"mode": "description", "lines": 20}, "succeeded": "remove"}
+		}`,
+		FormatYAML: `
+renderer: simple
+visible_lines: 10
+spinner: [a, b]
+theme:
+  name: dark
+  level: "256"
+  failed_title: bold white on red
+retention:
+  failed: {mode: description, lines: 20}
+  succeeded: remove
+`,
+		FormatTOML: `
+renderer = "simple"
+visible_lines = 10
+spinner = ["a", "b"]
+retention.failed = { mode = "description", lines = 20 }
+retention.succeeded = "remove"
+
+[theme]
+name = "dark"
+level = "256"
+failed_title = "bold white on red"
+`,
+	}
+	for format, document := range documents {
+		settings := NewDefaultSettings()
+		require.NoError(t, settings.Load([]byte(document), format), document)
+		assert.Equal(t, RendererSimple, settings.Renderer)
+		assert.Equal(t, 10, settings.Interactive.VisibleDescriptionLines)
+		assert.Equal(t, []string{"a", "b"}, settings.Interactive.ProgressIndicatorFrames)
+		theme := settings.Interactive.Theme
+		require.NotNil(t, theme)
+		assert.Same(t, theme, settings.Simple.Theme)
+		assert.Equal(t, terminal.ColorLevel256, theme.Level)
+		assert.Equal(t, terminal.DarkTheme().SucceededTitle, theme.SucceededTitle)
+		assert.Equal(t, terminal.Style{
+			Foreground: terminal.BasicColor(terminal.WhiteColor),
+			Background: terminal.BasicColor(terminal.RedColor),
+			Bold:       true,
+		}, theme.FailedTitle)
+		assert.Equal(t, echelon.RetentionPolicy{Mode: echelon.RetainDescription, Lines: 20},
+			settings.Interactive.RetentionWhenFailed)
+		assert.Equal(t, echelon.RetentionPolicy{Mode: echelon.RemoveScope}, settings.Interactive.RetentionWhenSucceeded)
+	}
+}
+
+func Test_Settings_LoadEnvironment(t *testing.T) {
+	t.Parallel()
+	settings := NewDefaultSettings()
+	require.NoError(t, settings.LoadEnvironment([]string{
+		"HOME=/root",
+		"ECHELON_VISIBLE_LINES=10",
+		"ECHELON_SPINNER=dots",
+		"ECHELON_SPINNER_CYCLE=500ms",
+		"ECHELON_THEME=none",
+		"ECHELON_LAYOUT=tree",
+		"ECHELON_SORT=failed-first",
+		"ECHELON_PIN_FAILED=true",
+		"ECHELON_GROUP=matrix",
+		"ECHELON_RETENTION_SKIPPED=title",
+		"ECHELON_TITLE_TEMPLATE={{.Status}} {{quote .Name}}",
+		"ECHELON_FAILED_TEMPLATE={{.Name}} failed",
+		"ECHELON_SIGNALS=restore",
+	}))
+	interactive := settings.Interactive
+	assert.Equal(t, 10, interactive.VisibleDescriptionLines)
+	assert.Equal(t, spinners["dots"], interactive.ProgressIndicatorFrames)
+	assert.Equal(t, 500*time.Millisecond, interactive.ProgressIndicatorCycleDuration)
+	assert.Nil(t, interactive.Theme)
+	assert.Equal(t, terminal.NoColorSchema(), interactive.Colors)
+	assert.Equal(t, terminal.NoColorSchema(), settings.Simple.Colors)
+	assert.Equal(t, config.LayoutTree, interactive.Layout)
+	assert.Equal(t, config.SortFailedFirst, interactive.SortOrder)
+	assert.True(t, interactive.PinFailedToBottom)
+	assert.Equal(t, "test", interactive.GroupKey([]string{"test (linux)"}))
+	assert.Equal(t, "test", settings.Simple.GroupKey([]string{"test (linux)"}))
+	assert.Equal(t, echelon.CollapseToTitle, interactive.RetentionWhenSkipped.Mode)
+	require.NotNil(t, interactive.TitleTemplate)
+	require.NotNil(t, settings.Simple.FailedTemplate)
+	assert.Nil(t, settings.Simple.SucceededTemplate)
+	assert.Equal(t, config.SignalsRestore, interactive.TerminationSignals)
+}
+
+func Test_Settings_SingleFrameSpinner(t *testing.T) {
+	t.Parallel()
+	for _, frame := range []string{"⣿", "*", "o"} {
+		settings := NewDefaultSettings()
+		require.NoError(t, settings.LoadEnvironment([]string{"ECHELON_SPINNER=" + frame}))
+		assert.Equal(t, []string{frame}, settings.Interactive.ProgressIndicatorFrames)
+	}
+}
+
+func Test_Settings_Errors(t *testing.T) {
+	t.Parallel()
+	for document, expected := range map[string]string{
+		`{"visible_lines": "ten"}`: `visible_lines: expected an integer, got "ten"`,
+		`{"visible_line": 10}`:     "visible_line: unknown setting",
+		`{"theme": {"failed_title": "redd"}}`: `theme.failed_title: unknown color "redd", ` +
+			"expected a name like red, a number up to 255 or #rrggbb",
+		`{"theme": {"colour": "red"}}`: "theme.colour: unknown setting",
+		`{"theme": "solarized"}`: `theme: unknown theme "solarized", ` +
+			"expected default, none, dark, light or high-contrast",
+		`{"layout": "grid"}`: `layout: unknown value "grid", expected one of indented, tree, ascii-tree`,
+		`{"spinner": "moon"}`: `spinner: unknown spinner "moon", ` +
+			"expected one of arc, clock, dots, line or a list of frames",
+		`{"hide_cursor": "sometimes"}`:              `hide_cursor: expected true or false, got "sometimes"`,
+		`{"retention": {"failed": {"lines": 1.5}}}`: "retention.failed.lines: expected an integer, got 1.5",
+		`{"title_template": "{{.Name"}`:             "title_template: template: title_template:1: unclosed action",
+	} {
+		err := NewDefaultSettings().Load([]byte(document), FormatJSON)
+		assert.EqualError(t, err, expected, document)
+	}
+
+	err := NewDefaultSettings().LoadEnvironment([]string{"ECHELON_VISIBLE_LINES=ten"})
+	assert.EqualError(t, err, `ECHELON_VISIBLE_LINES: expected an integer, got "ten"`)
+	// unknown variables might belong to the application
+	assert.NoError(t, NewDefaultSettings().LoadEnvironment([]string{"ECHELON_CONFIG=echelon.yaml"}))
+	err = NewDefaultSettings().LoadEnvironment([]string{"ECHELON_THEME_NAME=dark", "ECHELON_THEME=light"})
+	assert.EqualError(t, err, "ECHELON_THEME: the setting is already set as ECHELON_THEME_NAME")
+	err = NewDefaultSettings().Load([]byte(`{"theme.name": "light", "theme": {"name": "dark"}}`), FormatJSON)
+	assert.EqualError(t, err, "theme.name: the setting is set twice")
+	err = NewDefaultSettings().Load([]byte("retention:\n  failed: title\n  failed.mode: remove\n"), FormatYAML)
+	assert.EqualError(t, err, "retention.failed.mode: the setting is already set as retention.failed")
+}
+
+func Test_LoadSettings(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "echelon.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("visible_lines: 3\nmax_frame_rate: 10\n"), 0o600))
+	settings, err := LoadSettings(path, []string{"ECHELON_VISIBLE_LINES=7"})
+	require.NoError(t, err)
+	// environment variables override the file
+	assert.Equal(t, 7, settings.Interactive.VisibleDescriptionLines)
+	assert.Equal(t, 10, settings.Interactive.MaxFrameRate)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("fit_to_screen: maybe\n"), 0o600))
+	_, err = LoadSettings(path, nil)
+	var settingErr *SettingError
+	require.True(t, errors.As(err, &settingErr))
+	assert.Equal(t, path, settingErr.Source)
+	assert.Equal(t, "fit_to_screen", settingErr.Key)
+
+	tomlPath := filepath.Join(t.TempDir(), "echelon.toml")
+	require.NoError(t, ioutil.WriteFile(tomlPath, []byte("visible_lines =\n"), 0o600))
+	_, err = LoadSettings(tomlPath, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), tomlPath)
+
+	_, err = LoadSettings(filepath.Join(t.TempDir(), "echelon.ini"), nil)
+	assert.Error(t, err)
+}
+
+func Test_Settings_SingleThemeStyleKeepsDefaults(t *testing.T) {
+	t.Parallel()
+	settings := NewDefaultSettings()
+	require.NoError(t, settings.LoadEnvironment([]string{"ECHELON_THEME_FAILED_TITLE=bold red"}))
+	theme := settings.Interactive.Theme
+	require.NotNil(t, theme)
+	assert.Equal(t, terminal.Style{Foreground: terminal.BasicColor(terminal.RedColor), Bold: true}, theme.FailedTitle)
+	// the rest of the titles keep the colors of the default color schema
+	assert.Equal(t, terminal.Style{Foreground: terminal.BasicColor(terminal.GreenColor)}, theme.SucceededTitle)
+	assert.Equal(t, terminal.Style{Foreground: terminal.BasicColor(terminal.YellowColor)}, theme.RunningTitle)
+
+	settings = NewDefaultSettings()
+	require.NoError(t, settings.LoadEnvironment([]string{"ECHELON_THEME_LEVEL=256"}))
+	assert.Equal(t, terminal.ColorLevel256, settings.Interactive.Theme.Level)
+	assert.Equal(t, terminal.Style{Foreground: terminal.BasicColor(terminal.RedColor)},
+		settings.Interactive.Theme.FailedTitle)
+}
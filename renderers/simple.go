@@ -4,52 +4,124 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
 	"github.com/cirruslabs/echelon/renderers/internal/console"
 	"github.com/cirruslabs/echelon/terminal"
 	"github.com/cirruslabs/echelon/utils"
 )
 
 type SimpleRenderer struct {
-	out        io.Writer
-	colors     *terminal.ColorSchema
-	startTimes map[string]time.Time
+	out            io.Writer
+	colors         *terminal.ColorSchema
+	config         *config.SimpleRendererConfig
+	startTimes     map[string]time.Time
+	groupKey       config.GroupKeyFunc
+	groups         map[string]*scopeGroup
+	progress       map[string]*scopeProgress // children of scopes for templates
+	fields         map[string]map[string]string
+	templateErrors map[string]bool // errors of executing the templates that were already printed
 
 	StubRenderer
 }
 
+// scopeProgress counts children of a scope.
+type scopeProgress struct {
+	completed int
+	total     int
+}
+
+// scopeGroup tracks siblings with the same group key.
+type scopeGroup struct {
+	key     string
+	counts  config.GroupCounts
+	running map[string]bool
+}
+
 func NewSimpleRenderer(out io.Writer, colors *terminal.ColorSchema) *SimpleRenderer {
+	return NewSimpleRendererWithConfig(out, &config.SimpleRendererConfig{Colors: colors})
+}
+
+func NewSimpleRendererWithConfig(out io.Writer, rendererConfig *config.SimpleRendererConfig) *SimpleRenderer {
+	if rendererConfig == nil {
+		rendererConfig = config.NewDefaultSimpleRendererConfig()
+	}
+	colors := rendererConfig.Colors
 	if colors == nil {
 		colors = terminal.DefaultColorSchema()
 	}
 	_ = console.PrepareTerminalEnvironment()
 	return &SimpleRenderer{
-		out:        out,
-		colors:     colors,
-		startTimes: make(map[string]time.Time),
+		out:            out,
+		colors:         colors,
+		config:         rendererConfig,
+		startTimes:     make(map[string]time.Time),
+		groupKey:       rendererConfig.GroupKey,
+		groups:         make(map[string]*scopeGroup),
+		progress:       make(map[string]*scopeProgress),
+		fields:         make(map[string]map[string]string),
+		templateErrors: make(map[string]bool),
+	}
+}
+
+// SetGroupKey makes the renderer print a summary line like "test [4/6 passed, 1 failed]" every time all of
+// the started siblings with the same group key have finished. Nil disables the summaries.
+func (r *SimpleRenderer) SetGroupKey(key config.GroupKeyFunc) {
+	r.groupKey = key
+}
+
+// StartDrawing returns right away since the simple renderer prints everything as soon as it happens.
+func (r *SimpleRenderer) StartDrawing() {}
+
+// StopDrawing does nothing since there's nothing left to draw.
+func (r *SimpleRenderer) StopDrawing() {}
+
+// findGroup returns the group of the last of the scopes or nil if it doesn't belong to any.
+func (r *SimpleRenderer) findGroup(scopes []string) *scopeGroup {
+	if r.groupKey == nil {
+		return nil
+	}
+	key := r.groupKey(scopes)
+	if key == "" {
+		return nil
 	}
+	// the key takes the place of the name among the siblings
+	groupID := scopeKey(append(scopes[:len(scopes)-1:len(scopes)-1], key))
+	group, ok := r.groups[groupID]
+	if !ok {
+		group = &scopeGroup{key: key, running: make(map[string]bool)}
+		r.groups[groupID] = group
+	}
+	return group
 }
 
-func (r SimpleRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+func (r *SimpleRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
 	scopes := entry.GetScopes()
 	level := len(scopes)
 	if level == 0 {
 		return
 	}
-	timeKey := strings.Join(scopes, "/")
+	timeKey := scopeKey(scopes)
 	if _, ok := r.startTimes[timeKey]; ok {
 		// duplicate event
 		return
 	}
 	r.startTimes[timeKey] = time.Now()
-	lastScope := scopes[level-1]
-	message := terminal.GetColoredText(r.colors.NeutralColor, fmt.Sprintf("Started %s", quotedIfNeeded(lastScope)))
-	r.RenderRawMessage(message + "\n")
+	if group := r.findGroup(scopes); group != nil {
+		group.running[timeKey] = true
+		group.counts.Running++
+	}
+	r.parentProgress(scopes).total++
+	message := r.formatMessage(r.config.StartedTemplate, scopes, "started", 0, func() string {
+		return fmt.Sprintf("Started %s", quotedIfNeeded(scopes[level-1]))
+	})
+	r.RenderRawMessage(r.paint(config.StateRunning, r.colors.NeutralColor, message) + "\n")
 }
 
-func (r SimpleRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+func (r *SimpleRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
 	scopes := entry.GetScopes()
 	level := len(scopes)
 	if level == 0 {
@@ -57,44 +129,151 @@ func (r SimpleRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
 	}
 	now := time.Now()
 	startTime := now
-	if t, ok := r.startTimes[strings.Join(scopes, "/")]; ok {
+	if t, ok := r.startTimes[scopeKey(scopes)]; ok {
 		startTime = t
 	}
 	duration := now.Sub(startTime)
 	formatedDuration := utils.FormatDuration(duration, true)
 	lastScope := scopes[level-1]
+	r.parentProgress(scopes).completed++
 
 	switch entry.FinishType() {
 	case echelon.FinishTypeSucceeded:
-		message := fmt.Sprintf("%s succeeded in %s!", quotedIfNeeded(lastScope), formatedDuration)
-		coloredMessage := terminal.GetColoredText(r.colors.SuccessColor, message)
+		message := r.formatMessage(r.config.SucceededTemplate, scopes, "succeeded", duration, func() string {
+			return fmt.Sprintf("%s succeeded in %s!", quotedIfNeeded(lastScope), formatedDuration)
+		})
+		coloredMessage := r.paint(config.StateSucceeded, r.colors.SuccessColor, message)
 		r.RenderRawMessage(coloredMessage + "\n")
 	case echelon.FinishTypeFailed:
-		message := fmt.Sprintf("%s failed in %s!", quotedIfNeeded(lastScope), formatedDuration)
-		coloredMessage := terminal.GetColoredText(r.colors.FailureColor, message)
+		message := r.formatMessage(r.config.FailedTemplate, scopes, "failed", duration, func() string {
+			return fmt.Sprintf("%s failed in %s!", quotedIfNeeded(lastScope), formatedDuration)
+		})
+		coloredMessage := r.paint(config.StateFailed, r.colors.FailureColor, message)
 		r.RenderRawMessage(coloredMessage + "\n")
 	case echelon.FinishTypeSkipped:
-		message := fmt.Sprintf("%s skipped in %s!", quotedIfNeeded(lastScope), formatedDuration)
-		coloredMessage := terminal.GetColoredText(r.colors.NeutralColor, message)
+		message := r.formatMessage(r.config.SkippedTemplate, scopes, "skipped", duration, func() string {
+			return fmt.Sprintf("%s skipped in %s!", quotedIfNeeded(lastScope), formatedDuration)
+		})
+		coloredMessage := r.paint(config.StateSkipped, r.colors.NeutralColor, message)
 		r.RenderRawMessage(coloredMessage + "\n")
 	}
+	if group := r.findGroup(scopes); group != nil {
+		r.finishGroupMember(group, scopeKey(scopes), entry.FinishType())
+	}
+	// templates of the finished scope were the last users of its children and badges
+	scope := scopeKey(scopes)
+	delete(r.progress, scope)
+	delete(r.fields, scope)
+}
+
+// RenderBadge keeps the badge for the Fields of templates.
+func (r *SimpleRenderer) RenderBadge(entry *echelon.LogScopeBadge) {
+	scope := scopeKey(entry.GetScopes())
+	if entry.Text() == "" {
+		delete(r.fields[scope], entry.Name())
+		return
+	}
+	if r.fields[scope] == nil {
+		r.fields[scope] = make(map[string]string)
+	}
+	r.fields[scope][entry.Name()] = entry.Text()
+}
+
+func (r *SimpleRenderer) parentProgress(scopes []string) *scopeProgress {
+	parent := scopeKey(scopes[:len(scopes)-1])
+	result, ok := r.progress[parent]
+	if !ok {
+		result = &scopeProgress{}
+		r.progress[parent] = result
+	}
+	return result
+}
+
+// formatMessage executes the template or returns the default message if there's no template or it fails. Errors
+// of executing the template are printed once.
+func (r *SimpleRenderer) formatMessage(
+	tmpl *template.Template,
+	scopes []string,
+	status string,
+	duration time.Duration,
+	defaultMessage func() string,
+) string {
+	if tmpl == nil {
+		return defaultMessage()
+	}
+	scope := scopeKey(scopes)
+	data := config.TemplateData{
+		Name:      scopes[len(scopes)-1],
+		Path:      scopes,
+		Depth:     len(scopes),
+		Status:    status,
+		StartTime: r.startTimes[scope],
+		Duration:  duration,
+		Fields:    r.fields[scope],
+	}
+	if progress, ok := r.progress[scope]; ok {
+		data.Completed = progress.completed
+		data.Total = progress.total
+	}
+	var result strings.Builder
+	if err := tmpl.Execute(&result, data); err != nil {
+		if !r.templateErrors[err.Error()] {
+			r.templateErrors[err.Error()] = true
+			r.RenderRawMessage(err.Error() + "\n")
+		}
+		return defaultMessage()
+	}
+	return result.String()
+}
+
+func (r *SimpleRenderer) finishGroupMember(group *scopeGroup, memberKey string, finishType echelon.FinishType) {
+	if group.running[memberKey] {
+		delete(group.running, memberKey)
+		group.counts.Running--
+	}
+	switch finishType {
+	case echelon.FinishTypeSucceeded:
+		group.counts.Passed++
+	case echelon.FinishTypeFailed:
+		group.counts.Failed++
+	case echelon.FinishTypeSkipped:
+		group.counts.Skipped++
+	}
+	if group.counts.Running > 0 || group.counts.Total() < 2 {
+		return
+	}
+	state, color := config.StateSucceeded, r.colors.SuccessColor
+	if group.counts.Failed > 0 {
+		state, color = config.StateFailed, r.colors.FailureColor
+	} else if group.counts.Skipped == group.counts.Total() {
+		state, color = config.StateSkipped, r.colors.NeutralColor
+	}
+	message := fmt.Sprintf("%s [%s]", group.key, group.counts)
+	r.RenderRawMessage(r.paint(state, color, message) + "\n")
+}
+
+// paint styles the line like titles of scopes in the state with the theme or with the color if there's no theme.
+func (r *SimpleRenderer) paint(state config.ScopeState, color int, line string) string {
+	if theme := r.config.Theme; theme != nil {
+		return theme.Render(state.TitleStyle(theme), line)
+	}
+	return terminal.GetColoredText(color, line)
 }
 
-func (r SimpleRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+func (r *SimpleRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
 	r.RenderRawMessage(entry.GetMessage())
 }
 
-func (r SimpleRenderer) RenderRawMessage(message string) {
+func (r *SimpleRenderer) RenderRawMessage(message string) {
 	_, _ = r.out.Write([]byte(message))
 }
 
-func (r SimpleRenderer) ScopeHasStarted(scopes []string) bool {
+func (r *SimpleRenderer) ScopeHasStarted(scopes []string) bool {
 	level := len(scopes)
 	if level == 0 {
 		return true
 	}
-	timeKey := strings.Join(scopes, "/")
-	_, result := r.startTimes[timeKey]
+	_, result := r.startTimes[scopeKey(scopes)]
 	return result
 }
 
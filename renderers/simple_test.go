@@ -2,8 +2,15 @@
 package renderers
 
 import (
-	"github.com/stretchr/testify/assert"
+	"bytes"
+	"strings"
 	"testing"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_quotedIfNeeded(t *testing.T) {
@@ -18,3 +25,108 @@ func Test_quotedIfNeeded(t *testing.T) {
 	assert.Equal(t, "\"foo\" task", quotedIfNeeded("\"foo\" task"))
 	assert.Equal(t, "task \"foo\" has finished", quotedIfNeeded("task \"foo\" has finished"))
 }
+
+func Test_SimpleRenderer_GroupSummary(t *testing.T) {
+	t.Parallel()
+	var output bytes.Buffer
+	renderer := NewSimpleRenderer(&output, terminal.NoColorSchema())
+	renderer.SetGroupKey(config.GroupMatrix())
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("test (go1.21)"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("test (go1.22)"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("build"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "test (go1.21)"))
+	assert.NotContains(t, output.String(), "test [")
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "test (go1.22)"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "build"))
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, "test [1/2 passed, 1 failed]", lines[len(lines)-2])
+	assert.NotContains(t, output.String(), "build [")
+}
+
+func Test_SimpleRenderer_GroupsUnderScopesWithSlashes(t *testing.T) {
+	t.Parallel()
+	var output bytes.Buffer
+	renderer := NewSimpleRenderer(&output, terminal.NoColorSchema())
+	renderer.SetGroupKey(config.GroupMatrix())
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("a/b", "test (1)"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("a/b", "test (2)"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("a", "b", "test (3)"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "a/b", "test (1)"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "a/b", "test (2)"))
+	// the members under "a" and "b" are a different group that's still running
+	assert.Contains(t, output.String(), "test [2/2 passed]\n")
+}
+
+func Test_SimpleRenderer_Templates(t *testing.T) {
+	t.Parallel()
+	started, err := ParseTemplate("started", "{{.Depth}} {{index .Path 0}} > {{.Name}} {{.Status}}")
+	require.NoError(t, err)
+	succeeded, err := ParseTemplate("succeeded",
+		"{{quote .Name}} {{.Status}} {{.Completed}}/{{.Total}} {{.Fields.version}} {{len (formatDuration .Duration)}}")
+	require.NoError(t, err)
+	failed, err := ParseTemplate("failed", "{{.Missing}}")
+	require.NoError(t, err)
+	var output bytes.Buffer
+	renderer := NewSimpleRendererWithConfig(&output, &config.SimpleRendererConfig{
+		Colors:            terminal.NoColorSchema(),
+		StartedTemplate:   started,
+		SucceededTemplate: succeeded,
+		FailedTemplate:    failed,
+	})
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("parent"))
+	renderer.RenderBadge(echelon.NewLogScopeBadge("version", "v1.2", "parent"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("parent", "child"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "parent", "child"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("parent", "other"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeFailed, "parent", "other"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "parent"))
+	// the broken template falls back to the default message and its error is printed once
+	assert.Equal(t, []string{
+		"1 parent > parent started",
+		"2 parent > child started",
+		`template: failed:1:2: executing "failed" at <.Missing>: can't evaluate field Missing in type config.TemplateData`,
+		"'child' failed in 0.0s!",
+		"2 parent > other started",
+		"'other' failed in 0.0s!",
+		"'parent' succeeded 2/2 v1.2 4",
+	}, strings.Split(strings.TrimSpace(output.String()), "\n"))
+}
+
+func Test_SimpleRenderer_TemplatesOfScopesWithSlashes(t *testing.T) {
+	t.Parallel()
+	succeeded, err := ParseTemplate("succeeded", "{{.Name}} {{.Completed}}/{{.Total}} {{.Fields.version}}")
+	require.NoError(t, err)
+	var output bytes.Buffer
+	renderer := NewSimpleRendererWithConfig(&output, &config.SimpleRendererConfig{
+		Colors:            terminal.NoColorSchema(),
+		SucceededTemplate: succeeded,
+	})
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("a/b"))
+	renderer.RenderBadge(echelon.NewLogScopeBadge("version", "v1.2", "a/b"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("a"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("a", "b"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("a", "b", "c"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "a", "b", "c"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "a", "b"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "a/b"))
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, "b 1/1 <no value>", lines[len(lines)-2])
+	assert.Equal(t, "a/b 0/0 v1.2", lines[len(lines)-1])
+	assert.True(t, renderer.ScopeHasStarted([]string{"a", "b"}))
+	assert.False(t, renderer.ScopeHasStarted([]string{"a", "b/c"}))
+}
+
+func Test_SimpleRenderer_ForgetsFinishedScopes(t *testing.T) {
+	t.Parallel()
+	renderer := NewSimpleRenderer(&bytes.Buffer{}, terminal.NoColorSchema())
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("parent"))
+	renderer.RenderBadge(echelon.NewLogScopeBadge("version", "v1.2", "parent"))
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("parent", "child"))
+	renderer.RenderBadge(echelon.NewLogScopeBadge("version", "v1.3", "parent", "child"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "parent", "child"))
+	renderer.RenderScopeFinished(echelon.NewLogScopeFinished(echelon.FinishTypeSucceeded, "parent"))
+	assert.Empty(t, renderer.fields)
+	// only the root, which never finishes, still counts its children
+	assert.Len(t, renderer.progress, 1)
+	assert.Contains(t, renderer.progress, "")
+}
@@ -0,0 +1,158 @@
+package renderers
+
+import (
+	"os"
+	"sync"
+
+	"github.com/cirruslabs/echelon/renderers/internal/console"
+)
+
+// SizeProvider reports the size of the terminal a renderer draws to, e.g. the local terminal, a pty of an SSH session
+// or a web terminal.
+type SizeProvider interface {
+	// Size returns the width and the height of the terminal. Non-positive values mean that the size is unknown.
+	Size() (int, int)
+	// NotifyResize sends to the channel without blocking every time the size might have changed.
+	NotifyResize(c chan<- struct{})
+	// StopNotifyResize stops sending to the channel, nothing is sent to it once the call returns.
+	StopNotifyResize(c chan<- struct{})
+}
+
+// resizeSubscribers keeps the channels waiting for size changes.
+type resizeSubscribers struct {
+	lock     sync.Mutex
+	channels map[chan<- struct{}]bool
+}
+
+// add subscribes the channel and runs the callback under the lock if it's the first subscriber.
+func (s *resizeSubscribers) add(c chan<- struct{}, first func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.channels == nil {
+		s.channels = make(map[chan<- struct{}]bool)
+	}
+	s.channels[c] = true
+	if len(s.channels) == 1 && first != nil {
+		first()
+	}
+}
+
+// remove unsubscribes the channel and runs the callback under the lock if it was the last subscriber.
+func (s *resizeSubscribers) remove(c chan<- struct{}, last func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.channels[c] {
+		return
+	}
+	delete(s.channels, c)
+	if len(s.channels) == 0 && last != nil {
+		last()
+	}
+}
+
+func (s *resizeSubscribers) notify() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for c := range s.channels {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type fileSizeProvider struct {
+	resizeSubscribers
+	fileLock sync.Mutex
+	file     *os.File
+	signals  chan os.Signal
+}
+
+// NewFileSizeProvider reports the size of the terminal the file is attached to and relays window size changes
+// of the controlling terminal of the process.
+func NewFileSizeProvider(file *os.File) SizeProvider {
+	return &fileSizeProvider{file: file}
+}
+
+func (p *fileSizeProvider) Size() (int, int) {
+	p.fileLock.Lock()
+	defer p.fileLock.Unlock()
+	return console.TerminalSize(p.file)
+}
+
+// replaceFile continues querying another file descriptor of the same terminal, e.g. a duplicate of the original
+// one when standard streams are captured.
+func (p *fileSizeProvider) replaceFile(previous *os.File, file *os.File) {
+	p.fileLock.Lock()
+	defer p.fileLock.Unlock()
+	if p.file == previous {
+		p.file = file
+	}
+}
+
+func (p *fileSizeProvider) NotifyResize(c chan<- struct{}) {
+	p.add(c, func() {
+		signals := make(chan os.Signal, 1)
+		console.NotifyResize(signals)
+		go func() {
+			for range signals {
+				p.notify()
+			}
+		}()
+		p.signals = signals
+	})
+}
+
+func (p *fileSizeProvider) StopNotifyResize(c chan<- struct{}) {
+	p.remove(c, func() {
+		console.StopNotifyResize(p.signals)
+		close(p.signals)
+		p.signals = nil
+	})
+}
+
+type fixedSizeProvider struct {
+	width  int
+	height int
+}
+
+// NewFixedSizeProvider always reports the same size. Non-positive values mean that the size is unknown.
+func NewFixedSizeProvider(width int, height int) SizeProvider {
+	return fixedSizeProvider{width: width, height: height}
+}
+
+func (p fixedSizeProvider) Size() (int, int) {
+	return p.width, p.height
+}
+
+func (p fixedSizeProvider) NotifyResize(c chan<- struct{}) {}
+
+func (p fixedSizeProvider) StopNotifyResize(c chan<- struct{}) {}
+
+// CallbackSizeProvider asks the callback for the size every time it's needed. Call Resized once the size changes,
+// e.g. when an SSH client sends a window change request.
+type CallbackSizeProvider struct {
+	resizeSubscribers
+	size func() (int, int)
+}
+
+func NewCallbackSizeProvider(size func() (int, int)) *CallbackSizeProvider {
+	return &CallbackSizeProvider{size: size}
+}
+
+func (p *CallbackSizeProvider) Size() (int, int) {
+	return p.size()
+}
+
+// Resized notifies renderers that the size has changed.
+func (p *CallbackSizeProvider) Resized() {
+	p.notify()
+}
+
+func (p *CallbackSizeProvider) NotifyResize(c chan<- struct{}) {
+	p.add(c, nil)
+}
+
+func (p *CallbackSizeProvider) StopNotifyResize(c chan<- struct{}) {
+	p.remove(c, nil)
+}
@@ -0,0 +1,67 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cirruslabs/echelon"
+	"github.com/cirruslabs/echelon/renderers/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CallbackSizeProvider_Notifications(t *testing.T) {
+	t.Parallel()
+	provider := NewCallbackSizeProvider(func() (int, int) { return 80, 24 })
+	resizes := make(chan struct{}, 1)
+	provider.NotifyResize(resizes)
+	provider.Resized()
+	provider.Resized()
+	assert.Len(t, resizes, 1)
+	<-resizes
+	provider.StopNotifyResize(resizes)
+	provider.Resized()
+	assert.Len(t, resizes, 0)
+}
+
+func Test_InteractiveRendererForWriter_Resize(t *testing.T) {
+	t.Parallel()
+	var width int32 = 20
+	provider := NewCallbackSizeProvider(func() (int, int) {
+		return int(atomic.LoadInt32(&width)), 10
+	})
+	var screen screenRecorder
+	rendererConfig := config.NewDefaultSymbolsOnlyRenderingConfig()
+	rendererConfig.SynchronizedOutput = config.FeatureDisabled
+	renderer := NewInteractiveRendererForWriter(&screen, provider, rendererConfig)
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted("Scope with a rather long title"))
+	drawing := make(chan struct{})
+	go func() {
+		renderer.StartDrawing()
+		close(drawing)
+	}()
+	require.Eventually(t, func() bool {
+		return strings.Contains(screen.String(), "Scope with a rath…")
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NotContains(t, screen.String(), "long title")
+
+	atomic.StoreInt32(&width, 80)
+	provider.Resized()
+	require.Eventually(t, func() bool {
+		return strings.Contains(screen.String(), "Scope with a rather long title")
+	}, 5*time.Second, 10*time.Millisecond)
+	renderer.StopDrawing()
+	<-drawing
+}
+
+func Test_NewInteractiveRendererForWriter_UnknownSize(t *testing.T) {
+	t.Parallel()
+	var screen screenRecorder
+	renderer := NewInteractiveRendererForWriter(&screen, nil, config.NewDefaultSymbolsOnlyRenderingConfig())
+	renderer.RenderScopeStarted(echelon.NewLogScopeStarted(strings.Repeat("a", 200)))
+	renderer.DrawFrame()
+	assert.Contains(t, screen.String(), strings.Repeat("a", 200))
+}
@@ -0,0 +1,31 @@
+package renderers
+
+import (
+	"text/template"
+	"time"
+
+	"github.com/cirruslabs/echelon/utils"
+)
+
+// processStart is what offsets in templates are relative to.
+var processStart = time.Now()
+
+// templateFuncs are available in all templates in addition to the built-in ones.
+var templateFuncs = template.FuncMap{
+	// quote puts the text in single quotes unless it already has quotes
+	"quote": quotedIfNeeded,
+	// formatDuration formats a duration like 1.5s, 42s, 01:20 or 01:02:03
+	"formatDuration": func(duration time.Duration) string {
+		return utils.FormatDuration(duration, true)
+	},
+	// offset formats the time relative to the start of the process
+	"offset": func(t time.Time) string {
+		return utils.FormatDuration(t.Sub(processStart), true)
+	},
+}
+
+// ParseTemplate parses a title or a message template executed with config.TemplateData, e.g.
+// "{{.Status}} {{.ColoredName}} {{.FormattedDuration}}" or "[{{offset .StartTime}}] {{quote .Name}} failed".
+func ParseTemplate(name string, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
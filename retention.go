@@ -0,0 +1,24 @@
+package echelon
+
+// RetentionMode controls what's left of a scope on the screen once it finishes.
+type RetentionMode int
+
+const (
+	// RetentionDefault leaves the decision up to the renderer.
+	RetentionDefault RetentionMode = iota
+	// RetainChildren keeps the children and the last lines of output.
+	RetainChildren
+	// RetainDescription removes the children but keeps the last lines of output.
+	RetainDescription
+	// CollapseToTitle removes the children and the output leaving only the title.
+	CollapseToTitle
+	// RemoveScope removes the scope entirely.
+	RemoveScope
+)
+
+// RetentionPolicy describes what's left of a scope once it finishes.
+type RetentionPolicy struct {
+	Mode RetentionMode
+	// Lines is the amount of the last lines of output kept by RetainChildren and RetainDescription.
+	Lines int
+}
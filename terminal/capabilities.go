@@ -0,0 +1,24 @@
+package terminal
+
+import "strings"
+
+// SupportsSynchronizedOutput guesses from the environment if the terminal supports synchronized updates
+// (DEC private mode 2026). Querying the terminal directly would require reading its response from the input
+// which might be in use by the application. Terminals without the support simply ignore the mode.
+func SupportsSynchronizedOutput(getenv func(string) string) bool {
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "contour", "rio":
+		return true
+	}
+	if getenv("WT_SESSION") != "" {
+		// Windows Terminal
+		return true
+	}
+	term := getenv("TERM")
+	for _, prefix := range []string{"xterm-kitty", "xterm-ghostty", "foot", "alacritty", "contour", "wezterm"} {
+		if strings.HasPrefix(term, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package terminal_test
+
+import (
+	"testing"
+
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeEnv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func Test_SupportsSynchronizedOutput(t *testing.T) {
+	t.Parallel()
+	assert.True(t, terminal.SupportsSynchronizedOutput(fakeEnv(map[string]string{"TERM_PROGRAM": "WezTerm"})))
+	assert.True(t, terminal.SupportsSynchronizedOutput(fakeEnv(map[string]string{"TERM": "xterm-kitty"})))
+	assert.True(t, terminal.SupportsSynchronizedOutput(fakeEnv(map[string]string{"WT_SESSION": "42"})))
+	assert.False(t, terminal.SupportsSynchronizedOutput(fakeEnv(map[string]string{"TERM": "xterm-256color"})))
+	assert.False(t, terminal.SupportsSynchronizedOutput(fakeEnv(map[string]string{})))
+}
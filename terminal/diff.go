@@ -0,0 +1,93 @@
+package terminal
+
+// maxDiffEdits limits the edit distance the diff algorithm looks for. Frames that differ more are simply
+// rewritten line by line which is as efficient in such cases.
+const maxDiffEdits = 256
+
+type editKind int
+
+const (
+	editEqual editKind = iota
+	editDelete
+	editInsert
+)
+
+type edit struct {
+	kind  editKind
+	count int
+}
+
+// lineEdits finds the shortest sequence of line deletions and insertions transforming before into after using
+// Myers' algorithm. Consecutive edits of the same kind are merged. Returns nil if the frames differ too much.
+func lineEdits(before []string, after []string) []edit {
+	n, m := len(before), len(after)
+	maxEdits := n + m
+	if maxEdits > maxDiffEdits {
+		maxEdits = maxDiffEdits
+	}
+	offset := maxEdits + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] keeps furthest reaching x for diagonals -d..d before d-th step
+	var trace [][]int
+	for d := 0; d <= maxEdits; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && before[x] == after[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackEdits(trace, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrackEdits(trace [][]int, n int, m int) []edit {
+	var reversed []editKind
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		k := x - y
+		furthest := func(k int) int { return trace[d][k+d] }
+		var prevK int
+		if k == -d || (k != d && furthest(k-1) < furthest(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := furthest(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, editEqual)
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, editInsert)
+		} else {
+			reversed = append(reversed, editDelete)
+		}
+		x, y = prevX, prevY
+	}
+	for ; x > 0; x-- {
+		reversed = append(reversed, editEqual)
+	}
+	var result []edit
+	for i := len(reversed) - 1; i >= 0; i-- {
+		if len(result) > 0 && result[len(result)-1].kind == reversed[i] {
+			result[len(result)-1].count++
+		} else {
+			result = append(result, edit{kind: reversed[i], count: 1})
+		}
+	}
+	return result
+}
@@ -3,12 +3,15 @@ package terminal
 import (
 	"bufio"
 	"fmt"
+	"strings"
 )
 
 const (
 	eraseLine           = "\x1B[K" // clear entire line
 	eraseCursorDown     = "\x1B[J" // erase whole line
 	moveBeginningOfLine = "\r"
+	insertLines         = "\x1B[%dL" // insert blank lines at the cursor pushing the rest down
+	deleteLines         = "\x1B[%dM" // delete lines at the cursor pulling the rest up
 )
 
 func CalculateIncrementalUpdateMaxLines(output *bufio.Writer, linesBefore []string, linesAfter []string, maxLines int) {
@@ -42,6 +45,22 @@ func CalculateIncrementalUpdate(output *bufio.Writer, linesBefore []string, line
 		// no changes
 		return
 	}
+	var rewrite strings.Builder
+	rewriteLines(&rewrite, linesBefore, linesAfter)
+	update := rewrite.String()
+	if len(linesBefore) > 0 && len(linesAfter) > 0 {
+		// inserting or deleting lines in the middle might be cheaper than rewriting everything below
+		var diff strings.Builder
+		if diffLines(&diff, linesBefore, linesAfter) && diff.Len() < len(update) {
+			update = diff.String()
+		}
+	}
+	_, _ = output.WriteString(update)
+	_ = output.Flush()
+}
+
+// rewriteLines replaces lines that differ in place, appends new lines and erases the ones that are gone.
+func rewriteLines(output *strings.Builder, linesBefore []string, linesAfter []string) {
 	linesBeforeCount := len(linesBefore)
 	linesAfterCount := len(linesAfter)
 	linesMinCount := linesBeforeCount
@@ -82,7 +101,106 @@ func CalculateIncrementalUpdate(output *bufio.Writer, linesBefore []string, line
 		// erase everything down below
 		_, _ = output.WriteString(eraseCursorDown)
 	}
-	_ = output.Flush()
+}
+
+// diffLines transforms the frame using the minimal amount of line insertions and deletions.
+// Returns false if the frames are too different to find the edits.
+func diffLines(output *strings.Builder, linesBefore []string, linesAfter []string) bool {
+	edits := lineEdits(linesBefore, linesAfter)
+	if edits == nil {
+		return false
+	}
+	// lines inserted in the middle push the lines below further down so there must be enough space below the frame,
+	// otherwise lines at the bottom of the screen will be lost instead of being scrolled up
+	growth := len(linesAfter) - len(linesBefore)
+	if growth < 0 {
+		growth = 0
+	}
+	_, _ = output.WriteString(moveBeginningOfLine)
+	_, _ = output.WriteString(strings.Repeat("\n", growth))
+	cursor := &lineCursor{output: output, row: len(linesBefore) + growth}
+	// delete lines first so the frame never grows taller than the bigger of the two frames
+	position := 0
+	forEachChange(edits, func(equal int, deleted int, inserted int) {
+		position += equal
+		replaced := minInt(deleted, inserted)
+		position += replaced
+		if deleted > replaced {
+			cursor.moveTo(position)
+			_, _ = output.WriteString(fmt.Sprintf(deleteLines, deleted-replaced))
+		}
+	})
+	position = 0
+	forEachChange(edits, func(equal int, deleted int, inserted int) {
+		position += equal
+		replaced := minInt(deleted, inserted)
+		for i := 0; i < replaced; i++ {
+			cursor.moveTo(position)
+			_, _ = output.WriteString(eraseLine)
+			_, _ = output.WriteString(linesAfter[position])
+			_, _ = output.WriteString(moveBeginningOfLine)
+			position++
+		}
+		if inserted > replaced {
+			cursor.moveTo(position)
+			_, _ = output.WriteString(fmt.Sprintf(insertLines, inserted-replaced))
+			for i := replaced; i < inserted; i++ {
+				cursor.moveTo(position)
+				_, _ = output.WriteString(linesAfter[position])
+				_, _ = output.WriteString(moveBeginningOfLine)
+				position++
+			}
+		}
+	})
+	cursor.moveTo(len(linesAfter))
+	if len(linesBefore) > len(linesAfter) {
+		// lines pulled up from below the frame
+		_, _ = output.WriteString(eraseCursorDown)
+	}
+	return true
+}
+
+// forEachChange calls the function for every group of deletions and insertions with the amount of equal lines
+// before the group. Trailing equal lines are reported as a group without changes.
+func forEachChange(edits []edit, f func(equal int, deleted int, inserted int)) {
+	equal, deleted, inserted := 0, 0, 0
+	for _, e := range edits {
+		switch e.kind {
+		case editEqual:
+			if deleted > 0 || inserted > 0 {
+				f(equal, deleted, inserted)
+				equal, deleted, inserted = 0, 0, 0
+			}
+			equal += e.count
+		case editDelete:
+			deleted += e.count
+		case editInsert:
+			inserted += e.count
+		}
+	}
+	f(equal, deleted, inserted)
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// lineCursor keeps track of the row of the frame the cursor is at.
+type lineCursor struct {
+	output *strings.Builder
+	row    int
+}
+
+func (cursor *lineCursor) moveTo(row int) {
+	if row > cursor.row {
+		_, _ = cursor.output.WriteString(fmt.Sprintf("\x1B[%dB", row-cursor.row))
+	} else if row < cursor.row {
+		_, _ = cursor.output.WriteString(fmt.Sprintf("\x1B[%dA", cursor.row-row))
+	}
+	cursor.row = row
 }
 
 func commonElementsCount(one []string, two []string) int {
@@ -99,3 +217,33 @@ func commonElementsCount(one []string, two []string) int {
 	}
 	return minCount
 }
+
+// Repaint erases linesOnScreen previously drawn lines and draws linesAfter from scratch, keeping at most maxLines
+// of them if maxLines is positive. Unlike incremental updates it doesn't rely on what's left on the screen
+// which makes it safe to use after the terminal was resized.
+func Repaint(output *bufio.Writer, linesOnScreen int, linesAfter []string, maxLines int) {
+	Commit(output, linesOnScreen, nil, linesAfter, maxLines)
+}
+
+// Commit is like Repaint but also prints committedLines above the new frame. Committed lines are never cut
+// to maxLines so they end up in the scrollback and are not a part of the frame anymore.
+func Commit(output *bufio.Writer, linesOnScreen int, committedLines []string, linesAfter []string, maxLines int) {
+	if maxLines > 0 && len(linesAfter) > maxLines {
+		linesAfter = removeFirstElements(linesAfter, len(linesAfter)-maxLines)
+	}
+	_, _ = output.WriteString(moveBeginningOfLine)
+	if linesOnScreen > 0 {
+		// move up to the first line of the frame
+		_, _ = output.WriteString(fmt.Sprintf("\x1B[%dA", linesOnScreen))
+	}
+	_, _ = output.WriteString(eraseCursorDown)
+	for _, line := range committedLines {
+		_, _ = output.WriteString(line)
+		_, _ = output.WriteString("\n")
+	}
+	for _, line := range linesAfter {
+		_, _ = output.WriteString(line)
+		_, _ = output.WriteString("\n")
+	}
+	_ = output.Flush()
+}
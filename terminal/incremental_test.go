@@ -3,8 +3,12 @@ package terminal_test
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"github.com/cirruslabs/echelon/terminal"
 	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -40,3 +44,179 @@ func Test_calculateIncrementalUpdate_InplaceChange(t *testing.T) {
 	)
 	assert.Equal(t, "\r\u001B[2A\u001B[KUpdated Bar\r\u001B[2B", result.String())
 }
+
+func Test_Repaint(t *testing.T) {
+	t.Parallel()
+	var result bytes.Buffer
+	terminal.Repaint(
+		bufio.NewWriter(&result),
+		2,
+		[]string{"Foo", "Bar", "Baz"},
+		2,
+	)
+	assert.Equal(t, "\r\u001B[2A\u001B[JBar\nBaz\n", result.String())
+}
+
+// screen is a tiny VT100 emulator supporting just enough sequences to verify incremental updates.
+type screen struct {
+	rows   [][]rune
+	row    int
+	column int
+}
+
+func newScreen(height int) *screen {
+	return &screen{rows: make([][]rune, height)}
+}
+
+func (s *screen) Write(p []byte) (int, error) {
+	text := []rune(string(p))
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\r':
+			s.column = 0
+		case '\n':
+			s.column = 0
+			if s.row == len(s.rows)-1 {
+				s.rows = append(s.rows[1:], nil)
+			} else {
+				s.row++
+			}
+		case '\x1B':
+			end := i + 2
+			for text[end] < 0x40 || text[end] > 0x7E {
+				end++
+			}
+			count, err := strconv.Atoi(string(text[i+2 : end]))
+			if err != nil {
+				count = 1
+			}
+			s.control(text[end], count)
+			i = end
+		default:
+			for len(s.rows[s.row]) <= s.column {
+				s.rows[s.row] = append(s.rows[s.row], ' ')
+			}
+			s.rows[s.row][s.column] = text[i]
+			s.column++
+		}
+	}
+	return len(p), nil
+}
+
+func (s *screen) control(command rune, count int) {
+	switch command {
+	case 'A':
+		s.row -= count
+		if s.row < 0 {
+			s.row = 0
+		}
+	case 'B':
+		s.row += count
+		if s.row >= len(s.rows) {
+			s.row = len(s.rows) - 1
+		}
+	case 'H':
+		s.row = count - 1
+		s.column = 0
+	case 'K':
+		if len(s.rows[s.row]) > s.column {
+			s.rows[s.row] = s.rows[s.row][:s.column]
+		}
+	case 'J':
+		s.control('K', 1)
+		for i := s.row + 1; i < len(s.rows); i++ {
+			s.rows[i] = nil
+		}
+	case 'L':
+		for i := 0; i < count; i++ {
+			s.rows = append(s.rows[:s.row], append([][]rune{nil}, s.rows[s.row:len(s.rows)-1]...)...)
+		}
+	case 'M':
+		for i := 0; i < count; i++ {
+			s.rows = append(append(s.rows[:s.row:s.row], s.rows[s.row+1:]...), nil)
+		}
+	}
+}
+
+// lines returns the frame above the cursor.
+func (s *screen) lines() []string {
+	var result []string
+	for _, row := range s.rows[:s.row] {
+		result = append(result, string(row))
+	}
+	return result
+}
+
+func (s *screen) isBlankBelowCursor() bool {
+	for _, row := range s.rows[s.row:] {
+		if len(strings.TrimSpace(string(row))) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func randomFrame(random *rand.Rand, maxLines int) []string {
+	result := make([]string, random.Intn(maxLines+1))
+	for i := range result {
+		result[i] = string(rune('a' + random.Intn(4)))
+	}
+	return result
+}
+
+func Test_calculateIncrementalUpdate_RandomFrames(t *testing.T) {
+	t.Parallel()
+	random := rand.New(rand.NewSource(42))
+	for i := 0; i < 1000; i++ {
+		before := randomFrame(random, 12)
+		after := randomFrame(random, 12)
+		// keep the frame at the bottom of the screen to make sure no lines are lost while scrolling
+		s := newScreen(13)
+		output := bufio.NewWriter(s)
+		terminal.CalculateIncrementalUpdate(output, nil, before)
+		terminal.CalculateIncrementalUpdate(output, before, after)
+		if len(after) == 0 {
+			assert.Empty(t, s.lines(), "%v -> %v", before, after)
+		} else {
+			assert.Equal(t, after, s.lines(), "%v -> %v", before, after)
+		}
+		assert.True(t, s.isBlankBelowCursor(), "%v -> %v", before, after)
+	}
+}
+
+func largeFrame(size int) []string {
+	result := make([]string, size)
+	for i := range result {
+		result[i] = fmt.Sprintf("Line number %d of a large frame", i)
+	}
+	return result
+}
+
+func Test_calculateIncrementalUpdate_InsertInTheMiddle(t *testing.T) {
+	t.Parallel()
+	before := largeFrame(100)
+	after := append(append(append([]string{}, before[:10]...), "New line"), before[10:]...)
+	var result bytes.Buffer
+	terminal.CalculateIncrementalUpdate(bufio.NewWriter(&result), before, after)
+	// instead of repainting 90 lines below the new one only the new line is written
+	assert.Equal(t, "\r\n\u001B[91A\u001B[1LNew line\r\u001B[91B", result.String())
+}
+
+func Test_calculateIncrementalUpdate_RemoveFromTheMiddle(t *testing.T) {
+	t.Parallel()
+	before := largeFrame(100)
+	after := append(append([]string{}, before[:10]...), before[12:]...)
+	var result bytes.Buffer
+	terminal.CalculateIncrementalUpdate(bufio.NewWriter(&result), before, after)
+	assert.Equal(t, "\r\u001B[90A\u001B[2M\u001B[88B\u001B[J", result.String())
+}
+
+func Test_Commit(t *testing.T) {
+	t.Parallel()
+	s := newScreen(4)
+	output := bufio.NewWriter(s)
+	terminal.CalculateIncrementalUpdate(output, nil, []string{"Done", "Running"})
+	terminal.Commit(output, 2, []string{"Done", "Child 1", "Child 2"}, []string{"Running", "Output 1", "Output 2"}, 2)
+	// committed lines don't fit and were scrolled out of the screen while the frame was cut to 2 lines
+	assert.Equal(t, []string{"Child 2", "Output 1", "Output 2"}, s.lines())
+}
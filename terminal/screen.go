@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+)
+
+const (
+	enterAlternateScreen = "\x1B[?1049h"
+	leaveAlternateScreen = "\x1B[?1049l"
+	moveToRow            = "\x1B[%dH" // move to the beginning of the row, counting from one
+)
+
+// EnterAlternateScreen switches to the alternate screen buffer which keeps the scrollback of the primary one intact.
+func EnterAlternateScreen(output *bufio.Writer) {
+	_, _ = output.WriteString(enterAlternateScreen)
+	_ = output.Flush()
+}
+
+// LeaveAlternateScreen switches back to the primary screen buffer restoring its content and the cursor position.
+func LeaveAlternateScreen(output *bufio.Writer) {
+	_, _ = output.WriteString(leaveAlternateScreen)
+	_ = output.Flush()
+}
+
+// UpdateScreen redraws the lines of a frame that occupies the whole screen, e.g. the alternate screen buffer,
+// which differ from the previous frame. Lines are addressed by their row so the cursor can be anywhere.
+func UpdateScreen(output *bufio.Writer, linesBefore []string, linesAfter []string) {
+	for i, line := range linesAfter {
+		if i < len(linesBefore) && linesBefore[i] == line {
+			continue
+		}
+		_, _ = output.WriteString(fmt.Sprintf(moveToRow, i+1))
+		_, _ = output.WriteString(line)
+		_, _ = output.WriteString(eraseLine)
+	}
+	for i := len(linesAfter); i < len(linesBefore); i++ {
+		_, _ = output.WriteString(fmt.Sprintf(moveToRow, i+1))
+		_, _ = output.WriteString(eraseLine)
+	}
+	_ = output.Flush()
+}
@@ -0,0 +1,38 @@
+package terminal_test
+
+import (
+	"bufio"
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UpdateScreen_RandomFrames(t *testing.T) {
+	t.Parallel()
+	random := rand.New(rand.NewSource(42))
+	for i := 0; i < 1000; i++ {
+		before := randomFrame(random, 12)
+		after := randomFrame(random, 12)
+		s := newScreen(12)
+		output := bufio.NewWriter(s)
+		terminal.UpdateScreen(output, nil, before)
+		terminal.UpdateScreen(output, before, after)
+		for row, line := range s.rows {
+			expected := ""
+			if row < len(after) {
+				expected = after[row]
+			}
+			assert.Equal(t, expected, string(line), "%v -> %v", before, after)
+		}
+	}
+}
+
+func Test_UpdateScreen_OnlyChangedLines(t *testing.T) {
+	t.Parallel()
+	var result bytes.Buffer
+	terminal.UpdateScreen(bufio.NewWriter(&result), []string{"foo", "bar", "baz"}, []string{"foo", "qux"})
+	assert.Equal(t, "\x1B[2Hqux\x1B[K\x1B[3H\x1B[K", result.String())
+}
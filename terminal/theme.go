@@ -0,0 +1,447 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ColorLevel is how many colors a terminal supports.
+type ColorLevel int
+
+const (
+	// ColorLevelAuto detects the level from the environment of the process, see DetectColorLevel.
+	ColorLevelAuto ColorLevel = iota
+	// ColorLevelNone disables colors and text styles.
+	ColorLevelNone
+	// ColorLevelBasic supports the 8 basic colors.
+	ColorLevelBasic
+	// ColorLevel256 supports the 256 colors palette.
+	ColorLevel256
+	// ColorLevelTrueColor supports 24-bit RGB colors.
+	ColorLevelTrueColor
+)
+
+func (level ColorLevel) String() string {
+	switch level {
+	case ColorLevelNone:
+		return "none"
+	case ColorLevelBasic:
+		return "basic"
+	case ColorLevel256:
+		return "256"
+	case ColorLevelTrueColor:
+		return "truecolor"
+	default:
+		return "auto"
+	}
+}
+
+// ParseColorLevel parses one of "auto", "none", "basic", "256" or "truecolor".
+func ParseColorLevel(text string) (ColorLevel, error) {
+	for level := ColorLevelAuto; level <= ColorLevelTrueColor; level++ {
+		if strings.EqualFold(text, level.String()) {
+			return level, nil
+		}
+	}
+	return ColorLevelAuto, fmt.Errorf("unknown color level %q, expected auto, none, basic, 256 or truecolor", text)
+}
+
+var (
+	detectedColorLevelOnce sync.Once
+	detectedColorLevel     ColorLevel
+)
+
+// resolve replaces ColorLevelAuto with the level detected from the environment of the process.
+func (level ColorLevel) resolve() ColorLevel {
+	if level != ColorLevelAuto {
+		return level
+	}
+	detectedColorLevelOnce.Do(func() {
+		detectedColorLevel = DetectColorLevel(os.Getenv)
+	})
+	return detectedColorLevel
+}
+
+// DetectColorLevel guesses from the environment how many colors the terminal supports. NO_COLOR disables colors,
+// FORCE_COLOR set to 0-3 forces the level, COLORTERM announces 24-bit colors and TERM the 256 colors palette.
+func DetectColorLevel(getenv func(string) string) ColorLevel {
+	if getenv("NO_COLOR") != "" {
+		return ColorLevelNone
+	}
+	switch getenv("FORCE_COLOR") {
+	case "0", "false":
+		return ColorLevelNone
+	case "1", "true":
+		return ColorLevelBasic
+	case "2":
+		return ColorLevel256
+	case "3":
+		return ColorLevelTrueColor
+	}
+	switch getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return ColorLevelTrueColor
+	}
+	term := getenv("TERM")
+	switch {
+	case term == "dumb":
+		return ColorLevelNone
+	case strings.Contains(term, "truecolor") || strings.Contains(term, "direct"):
+		return ColorLevelTrueColor
+	case strings.Contains(term, "256"):
+		return ColorLevel256
+	}
+	if getenv("WT_SESSION") != "" {
+		// Windows Terminal
+		return ColorLevelTrueColor
+	}
+	return ColorLevelBasic
+}
+
+type colorKind uint8
+
+const (
+	defaultColorKind colorKind = iota
+	basicColorKind
+	paletteColorKind
+	rgbColorKind
+)
+
+// Color is one of the basic colors, a color of the 256 colors palette or a 24-bit RGB color. The zero value is
+// the default color of the terminal.
+type Color struct {
+	kind  colorKind
+	value uint32
+}
+
+// BasicColor returns one of the 8 basic colors like RedColor.
+func BasicColor(code int) Color {
+	return Color{kind: basicColorKind, value: uint32(code & 7)}
+}
+
+// PaletteColor returns a color of the 256 colors palette.
+func PaletteColor(index uint8) Color {
+	return Color{kind: paletteColorKind, value: uint32(index)}
+}
+
+// RGBColor returns a 24-bit color.
+func RGBColor(r uint8, g uint8, b uint8) Color {
+	return Color{kind: rgbColorKind, value: uint32(r)<<16 | uint32(g)<<8 | uint32(b)}
+}
+
+// basicColorNames are the names of the basic colors by their codes.
+var basicColorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// ParseColor parses a name of a basic color like "red", an index of the 256 colors palette like "208",
+// a 24-bit color like "#e06c75" or "default".
+func ParseColor(text string) (Color, error) {
+	text = strings.ToLower(text)
+	if text == "default" {
+		return Color{}, nil
+	}
+	for code, name := range basicColorNames {
+		if text == name {
+			return BasicColor(code), nil
+		}
+	}
+	if strings.HasPrefix(text, "#") && len(text) == len("#rrggbb") {
+		if value, err := strconv.ParseUint(text[1:], 16, 32); err == nil {
+			return RGBColor(uint8(value>>16), uint8(value>>8), uint8(value)), nil
+		}
+	}
+	if index, err := strconv.ParseUint(text, 10, 8); err == nil {
+		return PaletteColor(uint8(index)), nil
+	}
+	return Color{}, fmt.Errorf("unknown color %q, expected a name like red, a number up to 255 or #rrggbb", text)
+}
+
+func (color Color) IsDefault() bool {
+	return color.kind == defaultColorKind
+}
+
+func (color Color) rgb() (uint8, uint8, uint8) {
+	return uint8(color.value >> 16), uint8(color.value >> 8), uint8(color.value)
+}
+
+// downsample converts the color to the closest one supported at the level.
+func (color Color) downsample(level ColorLevel) Color {
+	switch {
+	case color.kind == defaultColorKind || level == ColorLevelNone:
+		return Color{}
+	case color.kind == rgbColorKind && level == ColorLevel256:
+		return PaletteColor(rgbToPalette(color.rgb()))
+	case color.kind == paletteColorKind && level == ColorLevelBasic:
+		if color.value < 16 {
+			return BasicColor(int(color.value))
+		}
+		return BasicColor(rgbToBasic(paletteToRGB(uint8(color.value))))
+	case color.kind == rgbColorKind && level == ColorLevelBasic:
+		return BasicColor(rgbToBasic(color.rgb()))
+	}
+	return color
+}
+
+// parameters returns SGR parameters selecting the color, base is 30 for foreground and 40 for background colors.
+func (color Color) parameters(base int) []string {
+	switch color.kind {
+	case basicColorKind:
+		return []string{strconv.Itoa(base + int(color.value))}
+	case paletteColorKind:
+		return []string{strconv.Itoa(base + 8), "5", strconv.Itoa(int(color.value))}
+	case rgbColorKind:
+		r, g, b := color.rgb()
+		return []string{strconv.Itoa(base + 8), "2", strconv.Itoa(int(r)), strconv.Itoa(int(g)), strconv.Itoa(int(b))}
+	default:
+		return nil
+	}
+}
+
+// cubeLevels are the intensities of the 6x6x6 color cube of the 256 colors palette.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+func nearestCubeIndex(value uint8) int {
+	result := 0
+	for i, level := range cubeLevels {
+		if abs(int(value)-level) < abs(int(value)-cubeLevels[result]) {
+			result = i
+		}
+	}
+	return result
+}
+
+// rgbToPalette returns the closest color of the cube or the grayscale ramp of the 256 colors palette.
+func rgbToPalette(r uint8, g uint8, b uint8) uint8 {
+	ri, gi, bi := nearestCubeIndex(r), nearestCubeIndex(g), nearestCubeIndex(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDistance := distance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+	average := (int(r) + int(g) + int(b)) / 3
+	grayIndex := (average - 8) / 10
+	if grayIndex < 0 {
+		grayIndex = 0
+	} else if grayIndex > 23 {
+		grayIndex = 23
+	}
+	gray := 8 + 10*grayIndex
+	if distance(r, g, b, gray, gray, gray) < cubeDistance {
+		return uint8(232 + grayIndex)
+	}
+	return uint8(cube)
+}
+
+// paletteToRGB returns the color of the cube or the grayscale ramp of the 256 colors palette.
+func paletteToRGB(index uint8) (uint8, uint8, uint8) {
+	if index >= 232 {
+		gray := uint8(8 + 10*(int(index)-232))
+		return gray, gray, gray
+	}
+	cube := int(index) - 16
+	return uint8(cubeLevels[cube/36]), uint8(cubeLevels[cube/6%6]), uint8(cubeLevels[cube%6])
+}
+
+// rgbToBasic picks one of the basic colors by the channels that are bright enough: bits of the codes of the basic
+// colors are red, green and blue.
+func rgbToBasic(r uint8, g uint8, b uint8) int {
+	const threshold = 128
+	result := 0
+	if r >= threshold {
+		result |= 1
+	}
+	if g >= threshold {
+		result |= 2
+	}
+	if b >= threshold {
+		result |= 4
+	}
+	return result
+}
+
+func distance(r uint8, g uint8, b uint8, r2 int, g2 int, b2 int) int {
+	dr, dg, db := int(r)-r2, int(g)-g2, int(b)-b2
+	return dr*dr + dg*dg + db*db
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Style of an element of the output. The zero value leaves the text as is.
+type Style struct {
+	Foreground Color
+	Background Color
+	Bold       bool
+	Dim        bool
+	Italic     bool
+	Underline  bool
+}
+
+// ParseStyle parses attributes and colors separated by spaces like "bold #e06c75" or "bold white on red".
+// Colors after "on" are backgrounds, see ParseColor for the rest. Empty text or "none" means no style.
+func ParseStyle(text string) (Style, error) {
+	var result Style
+	background := false
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		switch word {
+		case "none":
+		case "bold":
+			result.Bold = true
+		case "dim":
+			result.Dim = true
+		case "italic":
+			result.Italic = true
+		case "underline":
+			result.Underline = true
+		case "on":
+			background = true
+		default:
+			color, err := ParseColor(word)
+			if err != nil {
+				return Style{}, err
+			}
+			if background {
+				result.Background = color
+			} else {
+				result.Foreground = color
+			}
+		}
+	}
+	return result, nil
+}
+
+// Sequence returns the SGR sequence enabling the style with colors downsampled to the level
+// or an empty string if there's nothing to enable.
+func (style Style) Sequence(level ColorLevel) string {
+	level = level.resolve()
+	if level == ColorLevelNone {
+		return ""
+	}
+	var parameters []string
+	for _, attribute := range []struct {
+		enabled   bool
+		parameter string
+	}{{style.Bold, "1"}, {style.Dim, "2"}, {style.Italic, "3"}, {style.Underline, "4"}} {
+		if attribute.enabled {
+			parameters = append(parameters, attribute.parameter)
+		}
+	}
+	parameters = append(parameters, style.Foreground.downsample(level).parameters(30)...)
+	parameters = append(parameters, style.Background.downsample(level).parameters(40)...)
+	if len(parameters) == 0 {
+		return ""
+	}
+	return "\033[" + strings.Join(parameters, ";") + "m"
+}
+
+// Render styles the text with colors downsampled to the level.
+func (style Style) Render(text string, level ColorLevel) string {
+	sequence := style.Sequence(level)
+	if sequence == "" || text == "" {
+		return text
+	}
+	return sequence + text + ResetSequence
+}
+
+// Theme styles every element of the output separately.
+type Theme struct {
+	// Level limits the colors of the theme, the rest are downsampled to the closest supported ones.
+	Level ColorLevel
+
+	PendingTitle   Style
+	RunningTitle   Style
+	SucceededTitle Style
+	FailedTitle    Style
+	SkippedTitle   Style
+	Duration       Style
+	Spinner        Style
+	ErrorMessage   Style
+	WarnMessage    Style
+	InfoMessage    Style
+	DebugMessage   Style
+	TraceMessage   Style
+	Guides         Style
+}
+
+// Render styles the text with colors downsampled to the level of the theme.
+func (theme *Theme) Render(style Style, text string) string {
+	return style.Render(text, theme.Level)
+}
+
+// ColorSchemaTheme styles titles with the colors of the schema like renderers do without a theme, so that single
+// styles can be changed while keeping the rest of the default look.
+func ColorSchemaTheme(colors *ColorSchema) *Theme {
+	if colors == nil {
+		colors = DefaultColorSchema()
+	}
+	style := func(color int) Style {
+		if color == NoColor {
+			return Style{}
+		}
+		return Style{Foreground: BasicColor(color)}
+	}
+	return &Theme{
+		PendingTitle:   style(colors.NeutralColor),
+		RunningTitle:   style(colors.NeutralColor),
+		SucceededTitle: style(colors.SuccessColor),
+		FailedTitle:    style(colors.FailureColor),
+		SkippedTitle:   style(colors.NeutralColor),
+	}
+}
+
+// DarkTheme is for terminals with dark backgrounds.
+func DarkTheme() *Theme {
+	return &Theme{
+		PendingTitle:   Style{Dim: true},
+		RunningTitle:   Style{Foreground: RGBColor(229, 192, 123)},
+		SucceededTitle: Style{Foreground: RGBColor(152, 195, 121)},
+		FailedTitle:    Style{Foreground: RGBColor(224, 108, 117), Bold: true},
+		SkippedTitle:   Style{Foreground: RGBColor(130, 137, 151)},
+		Duration:       Style{Foreground: RGBColor(130, 137, 151)},
+		Spinner:        Style{Foreground: RGBColor(86, 182, 194)},
+		ErrorMessage:   Style{Foreground: RGBColor(224, 108, 117)},
+		WarnMessage:    Style{Foreground: RGBColor(229, 192, 123)},
+		DebugMessage:   Style{Dim: true},
+		TraceMessage:   Style{Dim: true, Italic: true},
+		Guides:         Style{Foreground: RGBColor(92, 99, 112)},
+	}
+}
+
+// LightTheme is for terminals with light backgrounds.
+func LightTheme() *Theme {
+	return &Theme{
+		PendingTitle:   Style{Dim: true},
+		RunningTitle:   Style{Foreground: RGBColor(152, 104, 1)},
+		SucceededTitle: Style{Foreground: RGBColor(56, 128, 55)},
+		FailedTitle:    Style{Foreground: RGBColor(202, 18, 67), Bold: true},
+		SkippedTitle:   Style{Foreground: RGBColor(105, 108, 119)},
+		Duration:       Style{Foreground: RGBColor(105, 108, 119)},
+		Spinner:        Style{Foreground: RGBColor(1, 132, 188)},
+		ErrorMessage:   Style{Foreground: RGBColor(202, 18, 67)},
+		WarnMessage:    Style{Foreground: RGBColor(152, 104, 1)},
+		DebugMessage:   Style{Foreground: RGBColor(105, 108, 119)},
+		TraceMessage:   Style{Foreground: RGBColor(105, 108, 119), Italic: true},
+		Guides:         Style{Foreground: RGBColor(160, 161, 167)},
+	}
+}
+
+// HighContrastTheme uses only the basic colors and bold text, so it looks the same in every terminal.
+func HighContrastTheme() *Theme {
+	return &Theme{
+		PendingTitle:   Style{Bold: true},
+		RunningTitle:   Style{Foreground: BasicColor(YellowColor), Bold: true},
+		SucceededTitle: Style{Foreground: BasicColor(GreenColor), Bold: true},
+		FailedTitle:    Style{Foreground: BasicColor(WhiteColor), Background: BasicColor(RedColor), Bold: true},
+		SkippedTitle:   Style{Foreground: BasicColor(CyanColor), Bold: true},
+		Duration:       Style{Bold: true},
+		Spinner:        Style{Foreground: BasicColor(YellowColor), Bold: true},
+		ErrorMessage:   Style{Foreground: BasicColor(RedColor), Bold: true},
+		WarnMessage:    Style{Foreground: BasicColor(YellowColor), Bold: true},
+		DebugMessage:   Style{Underline: true},
+		TraceMessage:   Style{Underline: true},
+		Guides:         Style{Bold: true},
+	}
+}
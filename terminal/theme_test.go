@@ -0,0 +1,98 @@
+package terminal_test
+
+import (
+	"testing"
+
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DetectColorLevel(t *testing.T) {
+	t.Parallel()
+	detect := func(env map[string]string) terminal.ColorLevel {
+		return terminal.DetectColorLevel(fakeEnv(env))
+	}
+	assert.Equal(t, terminal.ColorLevelNone, detect(map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}))
+	assert.Equal(t, terminal.ColorLevel256, detect(map[string]string{"FORCE_COLOR": "2", "TERM": "dumb"}))
+	assert.Equal(t, terminal.ColorLevelNone, detect(map[string]string{"FORCE_COLOR": "0"}))
+	assert.Equal(t, terminal.ColorLevelTrueColor, detect(map[string]string{"COLORTERM": "truecolor"}))
+	assert.Equal(t, terminal.ColorLevel256, detect(map[string]string{"TERM": "xterm-256color"}))
+	assert.Equal(t, terminal.ColorLevelNone, detect(map[string]string{"TERM": "dumb"}))
+	assert.Equal(t, terminal.ColorLevelBasic, detect(map[string]string{"TERM": "xterm"}))
+}
+
+func Test_Style_Sequence(t *testing.T) {
+	t.Parallel()
+	style := terminal.Style{
+		Foreground: terminal.RGBColor(255, 0, 0),
+		Background: terminal.PaletteColor(21),
+		Bold:       true,
+		Underline:  true,
+	}
+	assert.Equal(t, "\033[1;4;38;2;255;0;0;48;5;21m", style.Sequence(terminal.ColorLevelTrueColor))
+	assert.Equal(t, "\033[1;4;38;5;196;48;5;21m", style.Sequence(terminal.ColorLevel256))
+	assert.Equal(t, "\033[1;4;31;44m", style.Sequence(terminal.ColorLevelBasic))
+	assert.Equal(t, "", style.Sequence(terminal.ColorLevelNone))
+	assert.Equal(t, "", terminal.Style{}.Sequence(terminal.ColorLevelTrueColor))
+}
+
+func Test_Style_DownsamplesGrays(t *testing.T) {
+	t.Parallel()
+	style := terminal.Style{Foreground: terminal.RGBColor(128, 128, 128)}
+	assert.Equal(t, "\033[38;5;244m", style.Sequence(terminal.ColorLevel256))
+	assert.Equal(t, "\033[37m", style.Sequence(terminal.ColorLevelBasic))
+}
+
+func Test_Style_Render(t *testing.T) {
+	t.Parallel()
+	style := terminal.Style{Foreground: terminal.BasicColor(terminal.GreenColor), Italic: true}
+	assert.Equal(t, "\033[3;32mok\033[0m", style.Render("ok", terminal.ColorLevel256))
+	assert.Equal(t, "ok", style.Render("ok", terminal.ColorLevelNone))
+	assert.Equal(t, "", style.Render("", terminal.ColorLevel256))
+}
+
+func Test_BuiltInThemes(t *testing.T) {
+	t.Parallel()
+	for _, theme := range []*terminal.Theme{terminal.DarkTheme(), terminal.LightTheme(), terminal.HighContrastTheme()} {
+		theme.Level = terminal.ColorLevelBasic
+		assert.NotEqual(t, "failed", theme.Render(theme.FailedTitle, "failed"))
+		theme.Level = terminal.ColorLevelNone
+		assert.Equal(t, "failed", theme.Render(theme.FailedTitle, "failed"))
+	}
+}
+
+func Test_ParseStyle(t *testing.T) {
+	t.Parallel()
+	style, err := terminal.ParseStyle("bold #E06C75 on 21")
+	assert.NoError(t, err)
+	assert.Equal(t, terminal.Style{
+		Foreground: terminal.RGBColor(224, 108, 117),
+		Background: terminal.PaletteColor(21),
+		Bold:       true,
+	}, style)
+	style, err = terminal.ParseStyle("dim italic underline white on red")
+	assert.NoError(t, err)
+	assert.Equal(t, terminal.Style{
+		Foreground: terminal.BasicColor(terminal.WhiteColor),
+		Background: terminal.BasicColor(terminal.RedColor),
+		Dim:        true,
+		Italic:     true,
+		Underline:  true,
+	}, style)
+	style, err = terminal.ParseStyle("none")
+	assert.NoError(t, err)
+	assert.Equal(t, terminal.Style{}, style)
+	_, err = terminal.ParseStyle("bold redd")
+	assert.EqualError(t, err, `unknown color "redd", expected a name like red, a number up to 255 or #rrggbb`)
+	_, err = terminal.ParseStyle("256")
+	assert.Error(t, err)
+}
+
+func Test_ParseColorLevel(t *testing.T) {
+	t.Parallel()
+	level, err := terminal.ParseColorLevel("TrueColor")
+	assert.NoError(t, err)
+	assert.Equal(t, terminal.ColorLevelTrueColor, level)
+	_, err = terminal.ParseColorLevel("16")
+	assert.Error(t, err)
+}
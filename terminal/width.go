@@ -0,0 +1,164 @@
+package terminal
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// Ellipsis is appended to lines truncated to the terminal width.
+const Ellipsis = "…"
+
+const (
+	escape                  = '\x1B'
+	bell                    = '\a'
+	zeroWidthJoiner         = '\u200D'
+	textPresentation        = '\uFE0E'
+	emojiPresentation       = '\uFE0F'
+	regionalIndicatorFirst  = '\U0001F1E6'
+	regionalIndicatorLast   = '\U0001F1FF'
+	skinToneModifierFirst   = '\U0001F3FB'
+	skinToneModifierLast    = '\U0001F3FF'
+	tagFirst                = '\U000E0020'
+	tagLast                 = '\U000E007F'
+	selectGraphicRendition  = 'm'
+	controlSequenceStart    = '['
+	operatingSystemCommand  = ']'
+	stringTerminatorEscaped = '\\'
+)
+
+// StringWidth returns how many terminal cells the string occupies. ANSI escape sequences take no space,
+// East Asian wide characters and emoji take two cells and grapheme clusters joined by ZWJ are counted once.
+func StringWidth(s string) int {
+	result := 0
+	for i := 0; i < len(s); {
+		end, clusterWidth, _ := nextCluster(s, i)
+		result += clusterWidth
+		i = end
+	}
+	return result
+}
+
+// Truncate cuts the string so it fits into maxWidth cells including the tail which is appended if the string
+// was cut. ANSI escape sequences are preserved and colors are reset at the end of a truncated string.
+func Truncate(s string, maxWidth int, tail string) string {
+	if StringWidth(s) <= maxWidth {
+		return s
+	}
+	tailWidth := StringWidth(tail)
+	if tailWidth > maxWidth {
+		tail = ""
+		tailWidth = 0
+	}
+	var result strings.Builder
+	currentWidth := 0
+	colored := false
+	for i := 0; i < len(s); {
+		end, clusterWidth, isEscape := nextCluster(s, i)
+		if isEscape {
+			sequence := s[i:end]
+			if sequence[len(sequence)-1] == selectGraphicRendition && sequence[1] == controlSequenceStart {
+				colored = sequence != ResetSequence && sequence != "\x1B[m"
+			}
+			result.WriteString(sequence)
+		} else {
+			if currentWidth+clusterWidth+tailWidth > maxWidth {
+				break
+			}
+			currentWidth += clusterWidth
+			result.WriteString(s[i:end])
+		}
+		i = end
+	}
+	result.WriteString(tail)
+	if colored {
+		result.WriteString(ResetSequence)
+	}
+	return result.String()
+}
+
+// nextCluster finds the end of an escape sequence or a grapheme cluster starting at i and returns its width.
+func nextCluster(s string, i int) (int, int, bool) {
+	if s[i] == escape {
+		return escapeSequenceEnd(s, i), 0, true
+	}
+	r, size := utf8.DecodeRuneInString(s[i:])
+	clusterWidth := runeWidth(r)
+	end := i + size
+	isRegionalIndicator := r >= regionalIndicatorFirst && r <= regionalIndicatorLast
+	for end < len(s) {
+		next, nextSize := utf8.DecodeRuneInString(s[end:])
+		switch {
+		case next == zeroWidthJoiner:
+			// the joined character is a part of the same cluster
+			end += nextSize
+			if end < len(s) && s[end] != escape {
+				_, joinedSize := utf8.DecodeRuneInString(s[end:])
+				end += joinedSize
+			}
+		case next == emojiPresentation:
+			if clusterWidth == 1 {
+				clusterWidth = 2
+			}
+			end += nextSize
+		case next == textPresentation, isExtending(next):
+			end += nextSize
+		case isRegionalIndicator && next >= regionalIndicatorFirst && next <= regionalIndicatorLast:
+			// a pair of regional indicators is a single flag
+			isRegionalIndicator = false
+			clusterWidth = 2
+			end += nextSize
+		default:
+			return end, clusterWidth, false
+		}
+	}
+	return end, clusterWidth, false
+}
+
+func isExtending(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me) ||
+		(r >= skinToneModifierFirst && r <= skinToneModifierLast) ||
+		(r >= tagFirst && r <= tagLast)
+}
+
+func runeWidth(r rune) int {
+	if r < ' ' || (r >= 0x7F && r < 0xA0) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func escapeSequenceEnd(s string, i int) int {
+	if i+1 >= len(s) {
+		return len(s)
+	}
+	switch s[i+1] {
+	case controlSequenceStart:
+		// parameters and intermediate bytes are followed by a single final byte
+		for j := i + 2; j < len(s); j++ {
+			if s[j] >= 0x40 && s[j] <= 0x7E {
+				return j + 1
+			}
+		}
+		return len(s)
+	case operatingSystemCommand:
+		for j := i + 2; j < len(s); j++ {
+			if s[j] == bell {
+				return j + 1
+			}
+			if s[j] == escape && j+1 < len(s) && s[j+1] == stringTerminatorEscaped {
+				return j + 2
+			}
+		}
+		return len(s)
+	default:
+		return i + 2
+	}
+}
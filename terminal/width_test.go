@@ -0,0 +1,38 @@
+package terminal_test
+
+import (
+	"testing"
+
+	"github.com/cirruslabs/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StringWidth(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 3, terminal.StringWidth("Foo"))
+	assert.Equal(t, 3, terminal.StringWidth(terminal.GetColoredText(terminal.RedColor, "Foo")))
+	assert.Equal(t, 3, terminal.StringWidth("\x1B]8;;https://example.com\x1B\\Foo\x1B]8;;\a"))
+	assert.Equal(t, 4, terminal.StringWidth("日本"))
+	assert.Equal(t, 2, terminal.StringWidth("✅"))
+	assert.Equal(t, 2, terminal.StringWidth("❤️"))
+	assert.Equal(t, 2, terminal.StringWidth("👍🏽"))
+	assert.Equal(t, 2, terminal.StringWidth("👨‍👩‍👧‍👦"))
+	assert.Equal(t, 4, terminal.StringWidth("🇺🇸🇩🇪"))
+	assert.Equal(t, 4, terminal.StringWidth("café"))
+}
+
+func Test_Truncate(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "Foo", terminal.Truncate("Foo", 3, terminal.Ellipsis))
+	assert.Equal(t, "Fo…", terminal.Truncate("Foo bar", 3, terminal.Ellipsis))
+	assert.Equal(t, "日…", terminal.Truncate("日本語", 4, terminal.Ellipsis))
+	assert.Equal(t, "👨‍👩‍👧‍👦…", terminal.Truncate("👨‍👩‍👧‍👦👨‍👩‍👧‍👦", 3, terminal.Ellipsis))
+	assert.Equal(t,
+		"\x1B[31mFo…"+terminal.ResetSequence,
+		terminal.Truncate(terminal.GetColoredText(terminal.RedColor, "Foo bar"), 3, terminal.Ellipsis),
+	)
+	assert.Equal(t,
+		"\x1B[31mFoo"+terminal.ResetSequence+" …",
+		terminal.Truncate(terminal.GetColoredText(terminal.RedColor, "Foo")+" bar", 5, terminal.Ellipsis),
+	)
+}